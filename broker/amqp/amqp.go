@@ -0,0 +1,46 @@
+// Package amqp RabbitMQ (AMQP 0-9-1) broker implementation
+package amqp
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+//go:generate go tool moq -pkg amqp_test -stub -out amqp_mock_test.go . Channel
+
+// Channel defines the AMQP channel methods used by the Publisher and Subscriber. This is used for testing purposes.
+type Channel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	PublishWithContext(
+		ctx context.Context,
+		exchange, key string,
+		mandatory, immediate bool,
+		msg amqp.Publishing,
+	) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(
+		queue, consumer string,
+		autoAck, exclusive, noLocal, noWait bool,
+		args amqp.Table,
+	) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+	Close() error
+}
+
+// Option is a function to set options to Publisher or Subscriber.
+type Option func(any)
+
+// WithMessageIDKey modify default message id header key, used by Publisher and Subscriber.
+func WithMessageIDKey(key string) Option {
+	return func(c any) {
+		switch v := c.(type) {
+		case *Publisher:
+			v.msgIDKey = key
+		case *Subscriber:
+			v.msgIDKey = key
+		}
+	}
+}
@@ -0,0 +1,487 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package amqp_test
+
+import (
+	"context"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	brokeramqp "github.com/x4b1/messenger/broker/amqp"
+	"sync"
+)
+
+// Ensure, that ChannelMock does implement brokeramqp.Channel.
+// If this is not the case, regenerate this file with moq.
+var _ brokeramqp.Channel = &ChannelMock{}
+
+// ChannelMock is a mock implementation of brokeramqp.Channel.
+//
+//	func TestSomethingThatUsesChannel(t *testing.T) {
+//
+//		// make and configure a mocked brokeramqp.Channel
+//		mockedChannel := &ChannelMock{
+//			CancelFunc: func(consumer string, noWait bool) error {
+//				panic("mock out the Cancel method")
+//			},
+//			CloseFunc: func() error {
+//				panic("mock out the Close method")
+//			},
+//			ConfirmFunc: func(noWait bool) error {
+//				panic("mock out the Confirm method")
+//			},
+//			ConsumeFunc: func(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp091.Table) (<-chan amqp091.Delivery, error) {
+//				panic("mock out the Consume method")
+//			},
+//			NotifyPublishFunc: func(confirm chan amqp091.Confirmation) chan amqp091.Confirmation {
+//				panic("mock out the NotifyPublish method")
+//			},
+//			NotifyReturnFunc: func(c chan amqp091.Return) chan amqp091.Return {
+//				panic("mock out the NotifyReturn method")
+//			},
+//			PublishWithContextFunc: func(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp091.Publishing) error {
+//				panic("mock out the PublishWithContext method")
+//			},
+//			QosFunc: func(prefetchCount int, prefetchSize int, global bool) error {
+//				panic("mock out the Qos method")
+//			},
+//		}
+//
+//		// use mockedChannel in code that requires brokeramqp.Channel
+//		// and then make assertions.
+//
+//	}
+type ChannelMock struct {
+	// CancelFunc mocks the Cancel method.
+	CancelFunc func(consumer string, noWait bool) error
+
+	// CloseFunc mocks the Close method.
+	CloseFunc func() error
+
+	// ConfirmFunc mocks the Confirm method.
+	ConfirmFunc func(noWait bool) error
+
+	// ConsumeFunc mocks the Consume method.
+	ConsumeFunc func(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp091.Table) (<-chan amqp091.Delivery, error)
+
+	// NotifyPublishFunc mocks the NotifyPublish method.
+	NotifyPublishFunc func(confirm chan amqp091.Confirmation) chan amqp091.Confirmation
+
+	// NotifyReturnFunc mocks the NotifyReturn method.
+	NotifyReturnFunc func(c chan amqp091.Return) chan amqp091.Return
+
+	// PublishWithContextFunc mocks the PublishWithContext method.
+	PublishWithContextFunc func(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp091.Publishing) error
+
+	// QosFunc mocks the Qos method.
+	QosFunc func(prefetchCount int, prefetchSize int, global bool) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Cancel holds details about calls to the Cancel method.
+		Cancel []struct {
+			// Consumer is the consumer argument value.
+			Consumer string
+			// NoWait is the noWait argument value.
+			NoWait bool
+		}
+		// Close holds details about calls to the Close method.
+		Close []struct {
+		}
+		// Confirm holds details about calls to the Confirm method.
+		Confirm []struct {
+			// NoWait is the noWait argument value.
+			NoWait bool
+		}
+		// Consume holds details about calls to the Consume method.
+		Consume []struct {
+			// Queue is the queue argument value.
+			Queue string
+			// Consumer is the consumer argument value.
+			Consumer string
+			// AutoAck is the autoAck argument value.
+			AutoAck bool
+			// Exclusive is the exclusive argument value.
+			Exclusive bool
+			// NoLocal is the noLocal argument value.
+			NoLocal bool
+			// NoWait is the noWait argument value.
+			NoWait bool
+			// Args is the args argument value.
+			Args amqp091.Table
+		}
+		// NotifyPublish holds details about calls to the NotifyPublish method.
+		NotifyPublish []struct {
+			// Confirm is the confirm argument value.
+			Confirm chan amqp091.Confirmation
+		}
+		// NotifyReturn holds details about calls to the NotifyReturn method.
+		NotifyReturn []struct {
+			// C is the c argument value.
+			C chan amqp091.Return
+		}
+		// PublishWithContext holds details about calls to the PublishWithContext method.
+		PublishWithContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Exchange is the exchange argument value.
+			Exchange string
+			// Key is the key argument value.
+			Key string
+			// Mandatory is the mandatory argument value.
+			Mandatory bool
+			// Immediate is the immediate argument value.
+			Immediate bool
+			// Msg is the msg argument value.
+			Msg amqp091.Publishing
+		}
+		// Qos holds details about calls to the Qos method.
+		Qos []struct {
+			// PrefetchCount is the prefetchCount argument value.
+			PrefetchCount int
+			// PrefetchSize is the prefetchSize argument value.
+			PrefetchSize int
+			// Global is the global argument value.
+			Global bool
+		}
+	}
+	lockCancel             sync.RWMutex
+	lockClose              sync.RWMutex
+	lockConfirm            sync.RWMutex
+	lockConsume            sync.RWMutex
+	lockNotifyPublish      sync.RWMutex
+	lockNotifyReturn       sync.RWMutex
+	lockPublishWithContext sync.RWMutex
+	lockQos                sync.RWMutex
+}
+
+// Cancel calls CancelFunc.
+func (mock *ChannelMock) Cancel(consumer string, noWait bool) error {
+	callInfo := struct {
+		Consumer string
+		NoWait   bool
+	}{
+		Consumer: consumer,
+		NoWait:   noWait,
+	}
+	mock.lockCancel.Lock()
+	mock.calls.Cancel = append(mock.calls.Cancel, callInfo)
+	mock.lockCancel.Unlock()
+	if mock.CancelFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CancelFunc(consumer, noWait)
+}
+
+// CancelCalls gets all the calls that were made to Cancel.
+// Check the length with:
+//
+//	len(mockedChannel.CancelCalls())
+func (mock *ChannelMock) CancelCalls() []struct {
+	Consumer string
+	NoWait   bool
+} {
+	var calls []struct {
+		Consumer string
+		NoWait   bool
+	}
+	mock.lockCancel.RLock()
+	calls = mock.calls.Cancel
+	mock.lockCancel.RUnlock()
+	return calls
+}
+
+// Close calls CloseFunc.
+func (mock *ChannelMock) Close() error {
+	callInfo := struct {
+	}{}
+	mock.lockClose.Lock()
+	mock.calls.Close = append(mock.calls.Close, callInfo)
+	mock.lockClose.Unlock()
+	if mock.CloseFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CloseFunc()
+}
+
+// CloseCalls gets all the calls that were made to Close.
+// Check the length with:
+//
+//	len(mockedChannel.CloseCalls())
+func (mock *ChannelMock) CloseCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockClose.RLock()
+	calls = mock.calls.Close
+	mock.lockClose.RUnlock()
+	return calls
+}
+
+// Confirm calls ConfirmFunc.
+func (mock *ChannelMock) Confirm(noWait bool) error {
+	callInfo := struct {
+		NoWait bool
+	}{
+		NoWait: noWait,
+	}
+	mock.lockConfirm.Lock()
+	mock.calls.Confirm = append(mock.calls.Confirm, callInfo)
+	mock.lockConfirm.Unlock()
+	if mock.ConfirmFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.ConfirmFunc(noWait)
+}
+
+// ConfirmCalls gets all the calls that were made to Confirm.
+// Check the length with:
+//
+//	len(mockedChannel.ConfirmCalls())
+func (mock *ChannelMock) ConfirmCalls() []struct {
+	NoWait bool
+} {
+	var calls []struct {
+		NoWait bool
+	}
+	mock.lockConfirm.RLock()
+	calls = mock.calls.Confirm
+	mock.lockConfirm.RUnlock()
+	return calls
+}
+
+// Consume calls ConsumeFunc.
+func (mock *ChannelMock) Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp091.Table) (<-chan amqp091.Delivery, error) {
+	callInfo := struct {
+		Queue     string
+		Consumer  string
+		AutoAck   bool
+		Exclusive bool
+		NoLocal   bool
+		NoWait    bool
+		Args      amqp091.Table
+	}{
+		Queue:     queue,
+		Consumer:  consumer,
+		AutoAck:   autoAck,
+		Exclusive: exclusive,
+		NoLocal:   noLocal,
+		NoWait:    noWait,
+		Args:      args,
+	}
+	mock.lockConsume.Lock()
+	mock.calls.Consume = append(mock.calls.Consume, callInfo)
+	mock.lockConsume.Unlock()
+	if mock.ConsumeFunc == nil {
+		var (
+			deliveryChOut <-chan amqp091.Delivery
+			errOut        error
+		)
+		return deliveryChOut, errOut
+	}
+	return mock.ConsumeFunc(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// ConsumeCalls gets all the calls that were made to Consume.
+// Check the length with:
+//
+//	len(mockedChannel.ConsumeCalls())
+func (mock *ChannelMock) ConsumeCalls() []struct {
+	Queue     string
+	Consumer  string
+	AutoAck   bool
+	Exclusive bool
+	NoLocal   bool
+	NoWait    bool
+	Args      amqp091.Table
+} {
+	var calls []struct {
+		Queue     string
+		Consumer  string
+		AutoAck   bool
+		Exclusive bool
+		NoLocal   bool
+		NoWait    bool
+		Args      amqp091.Table
+	}
+	mock.lockConsume.RLock()
+	calls = mock.calls.Consume
+	mock.lockConsume.RUnlock()
+	return calls
+}
+
+// NotifyPublish calls NotifyPublishFunc.
+func (mock *ChannelMock) NotifyPublish(confirm chan amqp091.Confirmation) chan amqp091.Confirmation {
+	callInfo := struct {
+		Confirm chan amqp091.Confirmation
+	}{
+		Confirm: confirm,
+	}
+	mock.lockNotifyPublish.Lock()
+	mock.calls.NotifyPublish = append(mock.calls.NotifyPublish, callInfo)
+	mock.lockNotifyPublish.Unlock()
+	if mock.NotifyPublishFunc == nil {
+		var (
+			confirmationChOut chan amqp091.Confirmation
+		)
+		return confirmationChOut
+	}
+	return mock.NotifyPublishFunc(confirm)
+}
+
+// NotifyPublishCalls gets all the calls that were made to NotifyPublish.
+// Check the length with:
+//
+//	len(mockedChannel.NotifyPublishCalls())
+func (mock *ChannelMock) NotifyPublishCalls() []struct {
+	Confirm chan amqp091.Confirmation
+} {
+	var calls []struct {
+		Confirm chan amqp091.Confirmation
+	}
+	mock.lockNotifyPublish.RLock()
+	calls = mock.calls.NotifyPublish
+	mock.lockNotifyPublish.RUnlock()
+	return calls
+}
+
+// NotifyReturn calls NotifyReturnFunc.
+func (mock *ChannelMock) NotifyReturn(c chan amqp091.Return) chan amqp091.Return {
+	callInfo := struct {
+		C chan amqp091.Return
+	}{
+		C: c,
+	}
+	mock.lockNotifyReturn.Lock()
+	mock.calls.NotifyReturn = append(mock.calls.NotifyReturn, callInfo)
+	mock.lockNotifyReturn.Unlock()
+	if mock.NotifyReturnFunc == nil {
+		var (
+			returnChOut chan amqp091.Return
+		)
+		return returnChOut
+	}
+	return mock.NotifyReturnFunc(c)
+}
+
+// NotifyReturnCalls gets all the calls that were made to NotifyReturn.
+// Check the length with:
+//
+//	len(mockedChannel.NotifyReturnCalls())
+func (mock *ChannelMock) NotifyReturnCalls() []struct {
+	C chan amqp091.Return
+} {
+	var calls []struct {
+		C chan amqp091.Return
+	}
+	mock.lockNotifyReturn.RLock()
+	calls = mock.calls.NotifyReturn
+	mock.lockNotifyReturn.RUnlock()
+	return calls
+}
+
+// PublishWithContext calls PublishWithContextFunc.
+func (mock *ChannelMock) PublishWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp091.Publishing) error {
+	callInfo := struct {
+		Ctx       context.Context
+		Exchange  string
+		Key       string
+		Mandatory bool
+		Immediate bool
+		Msg       amqp091.Publishing
+	}{
+		Ctx:       ctx,
+		Exchange:  exchange,
+		Key:       key,
+		Mandatory: mandatory,
+		Immediate: immediate,
+		Msg:       msg,
+	}
+	mock.lockPublishWithContext.Lock()
+	mock.calls.PublishWithContext = append(mock.calls.PublishWithContext, callInfo)
+	mock.lockPublishWithContext.Unlock()
+	if mock.PublishWithContextFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.PublishWithContextFunc(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// PublishWithContextCalls gets all the calls that were made to PublishWithContext.
+// Check the length with:
+//
+//	len(mockedChannel.PublishWithContextCalls())
+func (mock *ChannelMock) PublishWithContextCalls() []struct {
+	Ctx       context.Context
+	Exchange  string
+	Key       string
+	Mandatory bool
+	Immediate bool
+	Msg       amqp091.Publishing
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Exchange  string
+		Key       string
+		Mandatory bool
+		Immediate bool
+		Msg       amqp091.Publishing
+	}
+	mock.lockPublishWithContext.RLock()
+	calls = mock.calls.PublishWithContext
+	mock.lockPublishWithContext.RUnlock()
+	return calls
+}
+
+// Qos calls QosFunc.
+func (mock *ChannelMock) Qos(prefetchCount int, prefetchSize int, global bool) error {
+	callInfo := struct {
+		PrefetchCount int
+		PrefetchSize  int
+		Global        bool
+	}{
+		PrefetchCount: prefetchCount,
+		PrefetchSize:  prefetchSize,
+		Global:        global,
+	}
+	mock.lockQos.Lock()
+	mock.calls.Qos = append(mock.calls.Qos, callInfo)
+	mock.lockQos.Unlock()
+	if mock.QosFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.QosFunc(prefetchCount, prefetchSize, global)
+}
+
+// QosCalls gets all the calls that were made to Qos.
+// Check the length with:
+//
+//	len(mockedChannel.QosCalls())
+func (mock *ChannelMock) QosCalls() []struct {
+	PrefetchCount int
+	PrefetchSize  int
+	Global        bool
+} {
+	var calls []struct {
+		PrefetchCount int
+		PrefetchSize  int
+		Global        bool
+	}
+	mock.lockQos.RLock()
+	calls = mock.calls.Qos
+	mock.lockQos.RUnlock()
+	return calls
+}
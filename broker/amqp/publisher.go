@@ -0,0 +1,302 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+// Publisher errors.
+var (
+	ErrNotConfirmed  = errors.New("message not confirmed by the broker")
+	ErrNotRouted     = errors.New("message not routed to any queue")
+	ErrChannelClosed = errors.New("amqp channel closed")
+)
+
+var _ broker.Broker = &Publisher{}
+
+// WithMetaRoutingKey setups the metadata key to get the routing key.
+func WithMetaRoutingKey(key string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.metaRoutingKey = key
+	}
+}
+
+// WithDefaultRoutingKey setups the default routing key.
+func WithDefaultRoutingKey(key string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.defaultRoutingKey = key
+	}
+}
+
+// Open returns a new Publisher instance connected to the given AMQP server url.
+func Open(url, exchange string, opts ...Option) (*Publisher, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to amqp server: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("opening amqp channel: %w", err)
+	}
+
+	p, err := New(ch, exchange, opts...)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+	p.conn = conn
+
+	return p, nil
+}
+
+// New returns a new Publisher instance that publishes to the given exchange.
+// It puts the channel in confirm mode, the channel must not be used to publish by others.
+// The broker confirmations are received in background until the channel is closed.
+func New(ch Channel, exchange string, opts ...Option) (*Publisher, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("enabling publisher confirms: %w", err)
+	}
+
+	p := Publisher{
+		ch:       ch,
+		waiters:  make(map[uint64]*confirmWaiter),
+		exchange: exchange,
+		msgIDKey: broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	go p.receiveConfirms(
+		ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		ch.NotifyReturn(make(chan amqp.Return, 1)),
+	)
+
+	return &p, nil
+}
+
+// Publisher publishes messages to an AMQP exchange, waiting for the broker confirmation of every message.
+type Publisher struct {
+	// channel where are going to publish messages
+	ch Channel
+	// connection opened by the publisher, nil if provided by the caller.
+	conn *amqp.Connection
+
+	// serializes publishing so every message gets the next delivery tag.
+	publishing sync.Mutex
+	// delivery tag of the last published message.
+	seq uint64
+
+	// guards the messages waiting confirmation by delivery tag.
+	mu      sync.Mutex
+	waiters map[uint64]*confirmWaiter
+	// set once the channel is closed, no more confirmations are received.
+	closed bool
+
+	// exchange where are going to publish messages
+	exchange string
+	// meta property of the message to use as routing key
+	metaRoutingKey string
+	// default routing key in case not provided in message metadata
+	defaultRoutingKey string
+	// header key where will be send the message id.
+	msgIDKey string
+}
+
+// confirmWaiter is a published message waiting its confirmation.
+type confirmWaiter struct {
+	msgID string
+	// set when the broker returns the message as unroutable, it is returned before the confirmation.
+	returned bool
+	result   chan error
+}
+
+// Publish publishes the given message to the exchange and waits until the broker confirms it,
+// if the broker rejects it returns ErrNotConfirmed. Messages are published as mandatory, if the exchange
+// does not route it to any queue it returns ErrNotRouted. Message metadata is sent as message headers.
+func (p *Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	md := msg.Metadata()
+	headers := make(amqp.Table, len(md)+1)
+	for k, v := range md {
+		headers[k] = v
+	}
+	headers[p.msgIDKey] = msg.ID()
+
+	p.publishing.Lock()
+	tag := p.seq + 1
+	w, err := p.wait(tag, msg.ID())
+	if err != nil {
+		p.publishing.Unlock()
+
+		return err
+	}
+
+	err = p.ch.PublishWithContext(ctx, p.exchange, p.routingKey(msg), true, false, amqp.Publishing{
+		Headers:      headers,
+		MessageId:    msg.ID(),
+		DeliveryMode: amqp.Persistent,
+		Body:         msg.Payload(),
+	})
+	if err != nil {
+		p.forget(tag)
+		p.publishing.Unlock()
+
+		return fmt.Errorf("publishing message: %w", err)
+	}
+	p.seq = tag
+	p.publishing.Unlock()
+
+	select {
+	case <-ctx.Done():
+		p.forget(tag)
+
+		return ctx.Err()
+	case err := <-w.result:
+		return err
+	}
+}
+
+// wait registers the message published with the given delivery tag as waiting confirmation.
+func (p *Publisher) wait(tag uint64, msgID string) (*confirmWaiter, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrChannelClosed
+	}
+	w := confirmWaiter{msgID: msgID, result: make(chan error, 1)}
+	p.waiters[tag] = &w
+
+	return &w, nil
+}
+
+// forget stops waiting the confirmation of the given delivery tag, it is discarded once received.
+func (p *Publisher) forget(tag uint64) {
+	p.mu.Lock()
+	delete(p.waiters, tag)
+	p.mu.Unlock()
+}
+
+// receiveConfirms routes the broker confirmations and returns to the messages waiting them
+// until the channel is closed, discarding the ones of messages no longer waited,
+// so the channel never blocks delivering them.
+func (p *Publisher) receiveConfirms(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	defer p.close()
+
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return
+			}
+			p.returned(r)
+		case c, ok := <-confirms:
+			if !ok {
+				return
+			}
+			// the broker sends the return of an unroutable message before its confirmation.
+			if !p.drainReturns(returns) {
+				return
+			}
+			p.confirmed(c)
+		}
+	}
+}
+
+// drainReturns handles the pending returns, it reports false if the channel is closed.
+func (p *Publisher) drainReturns(returns <-chan amqp.Return) bool {
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return false
+			}
+			p.returned(r)
+		default:
+			return true
+		}
+	}
+}
+
+// returned marks the waiting messages with the id of the returned message as unroutable.
+func (p *Publisher) returned(r amqp.Return) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range p.waiters {
+		if w.msgID == r.MessageId {
+			w.returned = true
+		}
+	}
+}
+
+// confirmed resolves the message waiting the given confirmation.
+func (p *Publisher) confirmed(c amqp.Confirmation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w, ok := p.waiters[c.DeliveryTag]
+	if !ok {
+		return
+	}
+	delete(p.waiters, c.DeliveryTag)
+
+	switch {
+	case !c.Ack:
+		w.result <- ErrNotConfirmed
+	case w.returned:
+		w.result <- ErrNotRouted
+	default:
+		w.result <- nil
+	}
+}
+
+// close fails the messages waiting confirmation once the channel is closed.
+func (p *Publisher) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for tag, w := range p.waiters {
+		w.result <- ErrChannelClosed
+		delete(p.waiters, tag)
+	}
+}
+
+// Close closes the connection if it was opened by the Publisher.
+func (p *Publisher) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+
+	return p.conn.Close()
+}
+
+// routingKey tries to get the routing key from message metadata
+// in case the message does not have the key it defaults to Publisher setup.
+func (p *Publisher) routingKey(msg messenger.Message) string {
+	if key, ok := msg.Metadata()[p.metaRoutingKey]; ok {
+		return key
+	}
+
+	return p.defaultRoutingKey
+}
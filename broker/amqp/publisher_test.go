@@ -0,0 +1,262 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	amqpx "github.com/x4b1/messenger/broker/amqp"
+)
+
+const exchange = "events"
+
+var errAMQP = errors.New("amqp error")
+
+// confirmingChannel returns a channel mock that confirms every published message with the given ack.
+func confirmingChannel(ack ...bool) *ChannelMock {
+	var (
+		confirms chan amqp.Confirmation
+		tag      uint64
+	)
+
+	return &ChannelMock{
+		NotifyPublishFunc: func(c chan amqp.Confirmation) chan amqp.Confirmation {
+			confirms = c
+			return c
+		},
+		PublishWithContextFunc: func(context.Context, string, string, bool, bool, amqp.Publishing) error {
+			tag++
+			confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: ack[int(tag-1)%len(ack)]}
+			return nil
+		},
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"aggregate_id": "29a7556a-ae85-4c1d-8f04-d57ed3122586", "type": "order.created"},
+		MsgPayload:  []byte("some message"),
+	}
+
+	for _, tc := range []struct {
+		name        string
+		opts        []amqpx.Option
+		expectedKey string
+		expectedID  string
+	}{
+		{
+			name:       "no routing key",
+			expectedID: broker.MessageIDKey,
+		},
+		{
+			name:        "default routing key",
+			opts:        []amqpx.Option{amqpx.WithDefaultRoutingKey("default")},
+			expectedKey: "default",
+			expectedID:  broker.MessageIDKey,
+		},
+		{
+			name: "metadata routing key",
+			opts: []amqpx.Option{
+				amqpx.WithDefaultRoutingKey("default"),
+				amqpx.WithMetaRoutingKey("type"),
+			},
+			expectedKey: "order.created",
+			expectedID:  broker.MessageIDKey,
+		},
+		{
+			name:       "custom message id key",
+			opts:       []amqpx.Option{amqpx.WithMessageIDKey("custom_key")},
+			expectedID: "custom_key",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ch := confirmingChannel(true)
+			p, err := amqpx.New(ch, exchange, tc.opts...)
+			require.NoError(t, err)
+
+			require.NoError(t, p.Publish(context.Background(), m))
+
+			require.Len(t, ch.ConfirmCalls(), 1)
+			calls := ch.PublishWithContextCalls()
+			require.Len(t, calls, 1)
+			require.Equal(t, exchange, calls[0].Exchange)
+			require.Equal(t, tc.expectedKey, calls[0].Key)
+			require.True(t, calls[0].Mandatory)
+			require.Equal(t, amqp.Publishing{
+				Headers: amqp.Table{
+					"aggregate_id": m.MsgMetadata["aggregate_id"],
+					"type":         m.MsgMetadata["type"],
+					tc.expectedID:  m.MsgID,
+				},
+				MessageId:    m.MsgID,
+				DeliveryMode: amqp.Persistent,
+				Body:         m.Payload(),
+			}, calls[0].Msg)
+		})
+	}
+}
+
+func TestPublishFails(t *testing.T) {
+	t.Parallel()
+
+	msg, err := messenger.NewMessage([]byte("some message"))
+	require.NoError(t, err)
+
+	t.Run("enabling confirms", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := amqpx.New(&ChannelMock{
+			ConfirmFunc: func(bool) error { return errAMQP },
+		}, exchange)
+		require.ErrorIs(t, err, errAMQP)
+	})
+
+	t.Run("publishing", func(t *testing.T) {
+		t.Parallel()
+
+		ch := confirmingChannel(true)
+		ch.PublishWithContextFunc = func(context.Context, string, string, bool, bool, amqp.Publishing) error {
+			return errAMQP
+		}
+		p, err := amqpx.New(ch, exchange)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, p.Publish(context.Background(), msg), errAMQP)
+	})
+
+	t.Run("broker rejects message", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := amqpx.New(confirmingChannel(false, true), exchange)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, p.Publish(context.Background(), msg), amqpx.ErrNotConfirmed)
+		require.NoError(t, p.Publish(context.Background(), msg))
+	})
+
+	t.Run("exchange does not route message", func(t *testing.T) {
+		t.Parallel()
+
+		unroutable, err := messenger.NewMessage([]byte("unroutable message"))
+		require.NoError(t, err)
+
+		var returns chan amqp.Return
+		ch := confirmingChannel(true)
+		ch.NotifyReturnFunc = func(c chan amqp.Return) chan amqp.Return {
+			returns = c
+			return c
+		}
+		confirm := ch.PublishWithContextFunc
+		ch.PublishWithContextFunc = func(
+			ctx context.Context,
+			exchange, key string,
+			mandatory, immediate bool,
+			msg amqp.Publishing,
+		) error {
+			if mandatory && msg.MessageId == unroutable.ID() {
+				returns <- amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", MessageId: msg.MessageId}
+			}
+			return confirm(ctx, exchange, key, mandatory, immediate, msg)
+		}
+		p, err := amqpx.New(ch, exchange)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, p.Publish(context.Background(), unroutable), amqpx.ErrNotRouted)
+		require.NoError(t, p.Publish(context.Background(), msg))
+	})
+
+	t.Run("channel closed", func(t *testing.T) {
+		t.Parallel()
+
+		ch := &ChannelMock{
+			NotifyPublishFunc: func(c chan amqp.Confirmation) chan amqp.Confirmation {
+				close(c)
+				return c
+			},
+		}
+		p, err := amqpx.New(ch, exchange)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, p.Publish(context.Background(), msg), amqpx.ErrChannelClosed)
+	})
+
+	t.Run("discards confirmations not waited", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			confirms chan amqp.Confirmation
+			tag      uint64
+		)
+		ch := &ChannelMock{
+			NotifyPublishFunc: func(c chan amqp.Confirmation) chan amqp.Confirmation {
+				confirms = c
+				return c
+			},
+			PublishWithContextFunc: func(context.Context, string, string, bool, bool, amqp.Publishing) error {
+				tag++
+				if tag == 2 {
+					confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+					go func() { confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true} }()
+				}
+				return nil
+			},
+		}
+		p, err := amqpx.New(ch, exchange)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, p.Publish(ctx, msg), context.Canceled)
+
+		require.NoError(t, p.Publish(context.Background(), msg))
+	})
+	t.Run("receives confirmations of messages no longer waited", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			confirms chan amqp.Confirmation
+			tag      uint64
+		)
+		ch := &ChannelMock{
+			NotifyPublishFunc: func(c chan amqp.Confirmation) chan amqp.Confirmation {
+				confirms = c
+				return c
+			},
+			PublishWithContextFunc: func(context.Context, string, string, bool, bool, amqp.Publishing) error {
+				if tag++; tag > 2 {
+					confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+				}
+				return nil
+			},
+		}
+		p, err := amqpx.New(ch, exchange)
+		require.NoError(t, err)
+
+		for range 2 {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			require.ErrorIs(t, p.Publish(ctx, msg), context.DeadlineExceeded)
+			cancel()
+		}
+
+		for tag := range uint64(2) {
+			select {
+			case confirms <- amqp.Confirmation{DeliveryTag: tag + 1, Ack: true}:
+			case <-time.After(time.Second):
+				t.Fatal("late confirmation blocked")
+			}
+		}
+
+		require.NoError(t, p.Publish(context.Background(), msg))
+	})
+}
@@ -0,0 +1,245 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const defaultConcurrency = 1
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// RequeuePolicy defines whether a message is requeued when the handler fails.
+// Messages failing with a permanent error are never requeued, so they are dead lettered
+// if the queue has a dead letter exchange.
+type RequeuePolicy int
+
+// Requeue policies.
+const (
+	// RequeueAlways requeues every failed message.
+	RequeueAlways RequeuePolicy = iota
+	// RequeueNever rejects failed messages without requeueing them.
+	RequeueNever
+	// RequeueOnce requeues failed messages unless they have already been redelivered.
+	RequeueOnce
+)
+
+// WithRequeuePolicy setups the policy to requeue failed messages, by default RequeueAlways.
+func WithRequeuePolicy(policy RequeuePolicy) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.requeue = policy
+	}
+}
+
+// WithPrefetch setups the max number of unacknowledged messages the broker delivers to the channel.
+func WithPrefetch(n int) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.prefetch = n
+	}
+}
+
+// WithConcurrency setups the number of messages of every subscription processed at the same time,
+// with more than one the messages are not processed in order.
+func WithConcurrency(n int) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.concurrency = n
+	}
+}
+
+// NewSubscriber returns a new Subscriber instance that consumes from the given channel,
+// the registered subscriptions names are the queues to consume.
+func NewSubscriber(ch Channel, opts ...Option) *Subscriber {
+	s := Subscriber{
+		ch:          ch,
+		subs:        make([]messenger.Subscription, 0),
+		errHandler:  log.NewDefault(),
+		concurrency: defaultConcurrency,
+		msgIDKey:    broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Subscriber consumes AMQP queues and handles the messages with the registered subscriptions.
+// Messages are acknowledged once handled, or negatively acknowledged following the requeue policy.
+type Subscriber struct {
+	ch Channel
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	requeue     RequeuePolicy
+	prefetch    int
+	concurrency int
+	// header key where the message id is received.
+	msgIDKey string
+}
+
+// Register adds one or more subscriptions to the Subscriber.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.subs = append(s.subs, subs...)
+}
+
+// Listen starts consuming the queues of all registered subscriptions.
+// It blocks until the context is cancelled or the channel is closed, returning ErrChannelClosed.
+// Once the context is cancelled it stops receiving messages and returns after the messages being
+// processed finish, handlers are not cancelled until Shutdown grace period ends.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen consumes the queue of every registered subscription until the context is done or the channel is closed.
+func (s *Subscriber) listen(ctx, handleCtx context.Context) error {
+	ctx, stopReceiving := context.WithCancel(ctx)
+	defer stopReceiving()
+
+	if s.prefetch > 0 {
+		if err := s.ch.Qos(s.prefetch, 0, false); err != nil {
+			return fmt.Errorf("setting prefetch: %w", err)
+		}
+	}
+
+	var (
+		wg        sync.WaitGroup
+		errMu     sync.Mutex
+		listenErr error
+	)
+	for _, sub := range s.subs {
+		deliveries, err := s.ch.Consume(sub.Name(), sub.Name(), false, false, false, false, nil)
+		if err != nil {
+			stopReceiving()
+			wg.Wait()
+
+			return fmt.Errorf("consuming %s: %w", sub.Name(), err)
+		}
+
+		var workers sync.WaitGroup
+		for range max(s.concurrency, 1) {
+			workers.Go(func() {
+				for d := range deliveries {
+					s.handle(handleCtx, sub, d)
+				}
+			})
+		}
+		consumed := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(consumed)
+		}()
+
+		// cancels the consumer once the context is done, the deliveries are closed once the pending ones
+		// are received. If the deliveries are closed before, the channel has been closed by the broker.
+		wg.Go(func() {
+			select {
+			case <-ctx.Done():
+				if err := s.ch.Cancel(sub.Name(), false); err != nil {
+					s.errHandler.Error(handleCtx, fmt.Errorf("cancelling consumer %s: %w", sub.Name(), err))
+				}
+				<-consumed
+			case <-consumed:
+				errMu.Lock()
+				if listenErr == nil {
+					listenErr = fmt.Errorf("consuming %s: %w", sub.Name(), ErrChannelClosed)
+				}
+				errMu.Unlock()
+				stopReceiving()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return listenErr
+}
+
+// handle processes the delivery with the subscription, acknowledging it on success.
+func (s *Subscriber) handle(ctx context.Context, sub messenger.Subscription, d amqp.Delivery) {
+	err := sub.Handle(ctx, s.parseDelivery(d))
+	if err == nil {
+		if err := d.Ack(false); err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("acknowledging message: %w", err))
+		}
+
+		return
+	}
+
+	s.errHandler.Error(ctx, err)
+
+	if err := d.Nack(false, s.shouldRequeue(d, err)); err != nil {
+		s.errHandler.Error(ctx, fmt.Errorf("negatively acknowledging message: %w", err))
+	}
+}
+
+// shouldRequeue applies the requeue policy to the failed delivery.
+func (s *Subscriber) shouldRequeue(d amqp.Delivery, err error) bool {
+	if messenger.IsPermanent(err) {
+		return false
+	}
+
+	switch s.requeue {
+	case RequeueNever:
+		return false
+	case RequeueOnce:
+		return !d.Redelivered
+	default:
+		return true
+	}
+}
+
+// parseDelivery transforms the delivery into a messenger message, restoring the message id
+// from the headers, if it is not present the message id property is used.
+func (s *Subscriber) parseDelivery(d amqp.Delivery) *messenger.GenericMessage {
+	msg := messenger.GenericMessage{
+		MsgPayload:  d.Body,
+		MsgMetadata: make(map[string]string, len(d.Headers)),
+	}
+	for k, v := range d.Headers {
+		if k == s.msgIDKey {
+			msg.MsgID = headerValue(v)
+			continue
+		}
+		msg.MsgMetadata[k] = headerValue(v)
+	}
+	if msg.MsgID == "" {
+		msg.MsgID = d.MessageId
+	}
+
+	return &msg
+}
+
+// headerValue returns the string representation of a header value.
+func headerValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,208 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	amqpx "github.com/x4b1/messenger/broker/amqp"
+)
+
+const queue = "test-queue"
+
+// acknowledger records the acknowledgements of the deliveries.
+type acknowledger struct {
+	mu      sync.Mutex
+	acks    []uint64
+	nacks   []uint64
+	requeue []bool
+}
+
+func (a *acknowledger) Ack(tag uint64, _ bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks = append(a.acks, tag)
+
+	return nil
+}
+
+func (a *acknowledger) Nack(tag uint64, _ bool, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks = append(a.nacks, tag)
+	a.requeue = append(a.requeue, requeue)
+
+	return nil
+}
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+// listen delivers the given deliveries to a subscriber with the handler and waits until all of them are handled.
+func listen(
+	t *testing.T,
+	deliveries []amqp.Delivery,
+	h messenger.SubscriptionHandler,
+	opts ...amqpx.Option,
+) (*acknowledger, *ChannelMock) {
+	t.Helper()
+
+	ack := &acknowledger{}
+	ch := make(chan amqp.Delivery, len(deliveries))
+	for _, d := range deliveries {
+		d.Acknowledger = ack
+		ch <- d
+	}
+
+	cli := &ChannelMock{
+		ConsumeFunc: func(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+			return ch, nil
+		},
+		CancelFunc: func(string, bool) error {
+			close(ch)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var handled sync.WaitGroup
+	handled.Add(len(deliveries))
+
+	s := amqpx.NewSubscriber(cli, opts...)
+	s.Register(messenger.NewSubscription(queue, func(ctx context.Context, msg messenger.Message) error {
+		defer handled.Done()
+		return h(ctx, msg)
+	}))
+
+	go func() {
+		handled.Wait()
+		cancel()
+	}()
+	require.NoError(t, s.Listen(ctx))
+
+	return ack, cli
+}
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handles and acks messages", func(t *testing.T) {
+		t.Parallel()
+
+		var received []messenger.Message
+		ack, cli := listen(t, []amqp.Delivery{
+			{
+				DeliveryTag: 1,
+				MessageId:   "amqp-id",
+				Headers:     amqp.Table{"aggregate_id": "123", broker.MessageIDKey: "custom-id", "count": int32(3)},
+				Body:        []byte("hello"),
+			},
+			{DeliveryTag: 2, MessageId: "amqp-id-2", Body: []byte("world")},
+		}, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg)
+			return nil
+		}, amqpx.WithPrefetch(10))
+
+		require.Equal(t, []messenger.Message{
+			&messenger.GenericMessage{
+				MsgID:       "custom-id",
+				MsgMetadata: messenger.Metadata{"aggregate_id": "123", "count": "3"},
+				MsgPayload:  []byte("hello"),
+			},
+			&messenger.GenericMessage{
+				MsgID:       "amqp-id-2",
+				MsgMetadata: messenger.Metadata{},
+				MsgPayload:  []byte("world"),
+			},
+		}, received)
+		require.Equal(t, []uint64{1, 2}, ack.acks)
+		require.Empty(t, ack.nacks)
+
+		require.Len(t, cli.QosCalls(), 1)
+		require.Equal(t, 10, cli.QosCalls()[0].PrefetchCount)
+		require.Equal(t, queue, cli.ConsumeCalls()[0].Queue)
+		require.False(t, cli.ConsumeCalls()[0].AutoAck)
+	})
+
+	handlerErr := errors.New("handler error")
+	deliveries := []amqp.Delivery{
+		{DeliveryTag: 1, Body: []byte("first")},
+		{DeliveryTag: 2, Body: []byte("redelivered"), Redelivered: true},
+	}
+
+	for _, tc := range []struct {
+		name            string
+		opts            []amqpx.Option
+		err             error
+		expectedRequeue []bool
+	}{
+		{
+			name:            "requeue always",
+			err:             handlerErr,
+			expectedRequeue: []bool{true, true},
+		},
+		{
+			name:            "requeue never",
+			opts:            []amqpx.Option{amqpx.WithRequeuePolicy(amqpx.RequeueNever)},
+			err:             handlerErr,
+			expectedRequeue: []bool{false, false},
+		},
+		{
+			name:            "requeue once",
+			opts:            []amqpx.Option{amqpx.WithRequeuePolicy(amqpx.RequeueOnce)},
+			err:             handlerErr,
+			expectedRequeue: []bool{true, false},
+		},
+		{
+			name:            "permanent error is never requeued",
+			err:             messenger.Permanent(handlerErr),
+			expectedRequeue: []bool{false, false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ack, _ := listen(t, deliveries, func(context.Context, messenger.Message) error {
+				return tc.err
+			}, tc.opts...)
+
+			require.Empty(t, ack.acks)
+			require.Equal(t, []uint64{1, 2}, ack.nacks)
+			require.Equal(t, tc.expectedRequeue, ack.requeue)
+		})
+	}
+
+	t.Run("fails consuming", func(t *testing.T) {
+		t.Parallel()
+
+		s := amqpx.NewSubscriber(&ChannelMock{
+			ConsumeFunc: func(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+				return nil, errAMQP
+			},
+		})
+		s.Register(messenger.NewSubscription(queue, nil))
+
+		require.ErrorIs(t, s.Listen(context.Background()), errAMQP)
+	})
+
+	t.Run("fails when channel is closed", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan amqp.Delivery)
+		close(ch)
+		s := amqpx.NewSubscriber(&ChannelMock{
+			ConsumeFunc: func(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+				return ch, nil
+			},
+		})
+		s.Register(messenger.NewSubscription(queue, nil))
+
+		require.ErrorIs(t, s.Listen(context.Background()), amqpx.ErrChannelClosed)
+	})
+}
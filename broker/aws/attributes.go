@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/x4b1/messenger"
+)
+
+const (
+	// maxAttributes is the max number of message attributes accepted by SNS and SQS.
+	maxAttributes = 10
+	// maxNumberPrecision is the max number of significant digits of a Number attribute.
+	maxNumberPrecision = 38
+	// maxNumberExponent and minNumberExponent are the range of a Number attribute magnitude, 10^-128 to 10^126.
+	maxNumberExponent = 126
+	minNumberExponent = -128
+)
+
+// numberFormat matches the decimal numbers accepted as Number attributes, captures the integer,
+// fraction and exponent parts.
+var numberFormat = regexp.MustCompile(`^[+-]?(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+
+// Errors returned when the message metadata cannot be sent as message attributes.
+var (
+	ErrTooManyAttributes = errors.New("too many message attributes")
+	ErrInvalidAttribute  = errors.New("invalid message attribute")
+)
+
+// AttributeType is the data type of a message attribute, custom types can be defined
+// adding a suffix to the base types, ex: Number.float.
+type AttributeType string
+
+// Message attribute base data types.
+const (
+	AttributeTypeString AttributeType = "String"
+	AttributeTypeNumber AttributeType = "Number"
+	AttributeTypeBinary AttributeType = "Binary"
+)
+
+// is reports whether the type is the given base type or a custom type of it.
+func (t AttributeType) is(base AttributeType) bool {
+	return t == base || strings.HasPrefix(string(t), string(base)+".")
+}
+
+// attribute is a message attribute independent of the SNS and SQS types.
+type attribute struct {
+	dataType AttributeType
+	value    string
+}
+
+// binary reports whether the attribute value has to be sent as binary.
+func (a attribute) binary() bool {
+	return a.dataType.is(AttributeTypeBinary)
+}
+
+// attributeEncoder transforms the message metadata into message attributes.
+type attributeEncoder struct {
+	// data type of the metadata keys, the rest are sent as String.
+	types map[string]AttributeType
+	// attribute where the metadata exceeding the attributes limit is packed as JSON,
+	// if empty exceeding the limit fails.
+	overflowKey string
+}
+
+// encode returns the message metadata and id as attributes. If they exceed the attributes limit and
+// the overflow attribute is setup, the message id, typed attributes and then the rest sorted by key
+// are kept as attributes, packing the remaining metadata as JSON in the overflow attribute.
+// Typed attributes are never packed, so it fails if they do not fit along with the message id,
+// and metadata using the overflow attribute key is rejected.
+func (e attributeEncoder) encode(msg messenger.Message, msgIDKey string) (map[string]attribute, error) {
+	md := maps.Clone(msg.Metadata())
+	if md == nil {
+		md = make(messenger.Metadata, 1)
+	}
+	if _, ok := md[e.overflowKey]; ok && e.overflowKey != "" {
+		return nil, fmt.Errorf("%w: %s is the overflow attribute key", ErrInvalidAttribute, e.overflowKey)
+	}
+	md[msgIDKey] = msg.ID()
+
+	keys := slices.SortedFunc(maps.Keys(md), func(a, b string) int {
+		// message id first, then typed attributes, then the rest by key.
+		pa, pb := e.priority(a, msgIDKey), e.priority(b, msgIDKey)
+		if pa != pb {
+			return pa - pb
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	if len(keys) > maxAttributes {
+		if e.overflowKey == "" {
+			return nil, fmt.Errorf("%w: %d, the max is %d", ErrTooManyAttributes, len(keys), maxAttributes)
+		}
+		if k := keys[maxAttributes-1]; e.priority(k, msgIDKey) < 2 {
+			return nil, fmt.Errorf("%w: typed attribute %s does not fit along with the overflow attribute",
+				ErrTooManyAttributes, k)
+		}
+		packed, err := json.Marshal(selectKeys(md, keys[maxAttributes-1:]))
+		if err != nil {
+			return nil, fmt.Errorf("packing overflow attributes: %w", err)
+		}
+		keys = keys[:maxAttributes-1]
+		md[e.overflowKey] = string(packed)
+		keys = append(keys, e.overflowKey)
+	}
+
+	att := make(map[string]attribute, len(keys))
+	for _, k := range keys {
+		t := AttributeTypeString
+		if v, ok := e.types[k]; ok && k != e.overflowKey {
+			t = v
+		}
+		if t.is(AttributeTypeNumber) && !validNumber(md[k]) {
+			return nil, fmt.Errorf("%w: %s value %q is not a number", ErrInvalidAttribute, k, md[k])
+		}
+		att[k] = attribute{dataType: t, value: md[k]}
+	}
+
+	return att, nil
+}
+
+// validNumber reports whether the value is a Number attribute accepted by SNS and SQS, a decimal number
+// with up to 38 significant digits and a magnitude between 10^-128 and 10^126, or zero.
+func validNumber(v string) bool {
+	m := numberFormat.FindStringSubmatch(v)
+	if m == nil || m[1]+m[2] == "" {
+		return false
+	}
+
+	exp := 0
+	if m[3] != "" {
+		var err error
+		if exp, err = strconv.Atoi(m[3]); err != nil {
+			return false
+		}
+	}
+
+	// value = digits * 10^exp, without leading nor trailing zeros in digits.
+	digits := strings.TrimLeft(m[1]+m[2], "0")
+	exp -= len(m[2])
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+	if digits == "" {
+		return true
+	}
+	if len(digits) > maxNumberPrecision {
+		return false
+	}
+
+	// magnitude is the exponent of the most significant digit.
+	magnitude := exp + len(digits) - 1
+
+	return magnitude >= minNumberExponent &&
+		(magnitude < maxNumberExponent || (magnitude == maxNumberExponent && digits == "1"))
+}
+
+// priority returns the order in which the key is kept as attribute when exceeding the limit.
+func (e attributeEncoder) priority(key, msgIDKey string) int {
+	switch {
+	case key == msgIDKey:
+		return 0
+	case e.types[key] != "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// selectKeys returns the metadata of the given keys.
+func selectKeys(md messenger.Metadata, keys []string) map[string]string {
+	selected := make(map[string]string, len(keys))
+	for _, k := range keys {
+		selected[k] = md[k]
+	}
+
+	return selected
+}
+
+// unpackAttributes adds the metadata packed in the overflow attribute to the attributes,
+// without replacing the existing ones.
+func unpackAttributes(att map[string]string, overflowKey string) {
+	packed, ok := att[overflowKey]
+	if overflowKey == "" || !ok {
+		return
+	}
+
+	var md map[string]string
+	if err := json.Unmarshal([]byte(packed), &md); err != nil {
+		return
+	}
+
+	delete(att, overflowKey)
+	for k, v := range md {
+		if _, ok := att[k]; !ok {
+			att[k] = v
+		}
+	}
+}
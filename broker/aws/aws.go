@@ -1,20 +1,34 @@
-// Package aws provides abstractions and clients for interacting with AWS SNS (Simple Notification Service)
-// and SQS (Simple Queue Service). It defines interfaces for the subset of SNS and SQS operations required
+// Package aws provides abstractions and clients for interacting with AWS SNS (Simple Notification Service),
+// SQS (Simple Queue Service), EventBridge and Kinesis. It defines interfaces for the subset of AWS operations required
 // by the application's publisher and subscriber components, enabling easier testing and mocking of AWS services.
 // The package is intended to be used as a bridge between the application logic and AWS messaging infrastructure.
 package aws
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 )
 
 var awsStringDataType = aws.String("String") //nolint: gochecknoglobals // aws constant
 
-//go:generate go tool moq -pkg aws_test -stub -out aws_mock_test.go . SNSClient SQSClient
+//go:generate go tool moq -pkg aws_test -stub -out aws_mock_test.go . SNSClient SQSClient EventBridgeClient KinesisClient
+
+// EntryError is returned for every message rejected by AWS within a batch request.
+type EntryError struct {
+	MessageID string
+	Code      string
+	Message   string
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("publishing message %s: %s: %s", e.MessageID, e.Code, e.Message)
+}
 
 // SNSClient defines the AWS SNS methods used by the Publisher. This is used for testing purposes.
 type SNSClient interface {
@@ -47,4 +61,37 @@ type SQSClient interface {
 		*sqs.GetQueueUrlInput,
 		...func(*sqs.Options),
 	) (*sqs.GetQueueUrlOutput, error)
+	DeleteMessageBatch(
+		context.Context,
+		*sqs.DeleteMessageBatchInput,
+		...func(*sqs.Options),
+	) (*sqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibility(
+		context.Context,
+		*sqs.ChangeMessageVisibilityInput,
+		...func(*sqs.Options),
+	) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// EventBridgeClient defines the AWS EventBridge methods used by the Publisher. This is used for testing purposes.
+type EventBridgeClient interface {
+	PutEvents(
+		ctx context.Context,
+		params *eventbridge.PutEventsInput,
+		optFns ...func(*eventbridge.Options),
+	) (*eventbridge.PutEventsOutput, error)
+}
+
+// KinesisClient defines the AWS Kinesis methods used by the Publisher. This is used for testing purposes.
+type KinesisClient interface {
+	PutRecord(
+		ctx context.Context,
+		params *kinesis.PutRecordInput,
+		optFns ...func(*kinesis.Options),
+	) (*kinesis.PutRecordOutput, error)
+	PutRecords(
+		ctx context.Context,
+		params *kinesis.PutRecordsInput,
+		optFns ...func(*kinesis.Options),
+	) (*kinesis.PutRecordsOutput, error)
 }
@@ -5,6 +5,8 @@ package aws_test
 
 import (
 	"context"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/x4b1/messenger/broker/aws"
@@ -103,9 +105,15 @@ var _ aws.SQSClient = &SQSClientMock{}
 //
 //		// make and configure a mocked aws.SQSClient
 //		mockedSQSClient := &SQSClientMock{
+//			ChangeMessageVisibilityFunc: func(contextMoqParam context.Context, changeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput, fns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+//				panic("mock out the ChangeMessageVisibility method")
+//			},
 //			DeleteMessageFunc: func(contextMoqParam context.Context, deleteMessageInput *sqs.DeleteMessageInput, fns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
 //				panic("mock out the DeleteMessage method")
 //			},
+//			DeleteMessageBatchFunc: func(contextMoqParam context.Context, deleteMessageBatchInput *sqs.DeleteMessageBatchInput, fns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+//				panic("mock out the DeleteMessageBatch method")
+//			},
 //			GetQueueUrlFunc: func(contextMoqParam context.Context, getQueueUrlInput *sqs.GetQueueUrlInput, fns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
 //				panic("mock out the GetQueueUrl method")
 //			},
@@ -122,9 +130,15 @@ var _ aws.SQSClient = &SQSClientMock{}
 //
 //	}
 type SQSClientMock struct {
+	// ChangeMessageVisibilityFunc mocks the ChangeMessageVisibility method.
+	ChangeMessageVisibilityFunc func(contextMoqParam context.Context, changeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput, fns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+
 	// DeleteMessageFunc mocks the DeleteMessage method.
 	DeleteMessageFunc func(contextMoqParam context.Context, deleteMessageInput *sqs.DeleteMessageInput, fns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
 
+	// DeleteMessageBatchFunc mocks the DeleteMessageBatch method.
+	DeleteMessageBatchFunc func(contextMoqParam context.Context, deleteMessageBatchInput *sqs.DeleteMessageBatchInput, fns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+
 	// GetQueueUrlFunc mocks the GetQueueUrl method.
 	GetQueueUrlFunc func(contextMoqParam context.Context, getQueueUrlInput *sqs.GetQueueUrlInput, fns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
 
@@ -136,6 +150,15 @@ type SQSClientMock struct {
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// ChangeMessageVisibility holds details about calls to the ChangeMessageVisibility method.
+		ChangeMessageVisibility []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// ChangeMessageVisibilityInput is the changeMessageVisibilityInput argument value.
+			ChangeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput
+			// Fns is the fns argument value.
+			Fns []func(*sqs.Options)
+		}
 		// DeleteMessage holds details about calls to the DeleteMessage method.
 		DeleteMessage []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -145,6 +168,15 @@ type SQSClientMock struct {
 			// Fns is the fns argument value.
 			Fns []func(*sqs.Options)
 		}
+		// DeleteMessageBatch holds details about calls to the DeleteMessageBatch method.
+		DeleteMessageBatch []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// DeleteMessageBatchInput is the deleteMessageBatchInput argument value.
+			DeleteMessageBatchInput *sqs.DeleteMessageBatchInput
+			// Fns is the fns argument value.
+			Fns []func(*sqs.Options)
+		}
 		// GetQueueUrl holds details about calls to the GetQueueUrl method.
 		GetQueueUrl []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -173,10 +205,56 @@ type SQSClientMock struct {
 			Fns []func(*sqs.Options)
 		}
 	}
-	lockDeleteMessage  sync.RWMutex
-	lockGetQueueUrl    sync.RWMutex
-	lockReceiveMessage sync.RWMutex
-	lockSendMessage    sync.RWMutex
+	lockChangeMessageVisibility sync.RWMutex
+	lockDeleteMessage           sync.RWMutex
+	lockDeleteMessageBatch      sync.RWMutex
+	lockGetQueueUrl             sync.RWMutex
+	lockReceiveMessage          sync.RWMutex
+	lockSendMessage             sync.RWMutex
+}
+
+// ChangeMessageVisibility calls ChangeMessageVisibilityFunc.
+func (mock *SQSClientMock) ChangeMessageVisibility(contextMoqParam context.Context, changeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput, fns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	callInfo := struct {
+		ContextMoqParam              context.Context
+		ChangeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput
+		Fns                          []func(*sqs.Options)
+	}{
+		ContextMoqParam:              contextMoqParam,
+		ChangeMessageVisibilityInput: changeMessageVisibilityInput,
+		Fns:                          fns,
+	}
+	mock.lockChangeMessageVisibility.Lock()
+	mock.calls.ChangeMessageVisibility = append(mock.calls.ChangeMessageVisibility, callInfo)
+	mock.lockChangeMessageVisibility.Unlock()
+	if mock.ChangeMessageVisibilityFunc == nil {
+		var (
+			changeMessageVisibilityOutputOut *sqs.ChangeMessageVisibilityOutput
+			errOut                           error
+		)
+		return changeMessageVisibilityOutputOut, errOut
+	}
+	return mock.ChangeMessageVisibilityFunc(contextMoqParam, changeMessageVisibilityInput, fns...)
+}
+
+// ChangeMessageVisibilityCalls gets all the calls that were made to ChangeMessageVisibility.
+// Check the length with:
+//
+//	len(mockedSQSClient.ChangeMessageVisibilityCalls())
+func (mock *SQSClientMock) ChangeMessageVisibilityCalls() []struct {
+	ContextMoqParam              context.Context
+	ChangeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput
+	Fns                          []func(*sqs.Options)
+} {
+	var calls []struct {
+		ContextMoqParam              context.Context
+		ChangeMessageVisibilityInput *sqs.ChangeMessageVisibilityInput
+		Fns                          []func(*sqs.Options)
+	}
+	mock.lockChangeMessageVisibility.RLock()
+	calls = mock.calls.ChangeMessageVisibility
+	mock.lockChangeMessageVisibility.RUnlock()
+	return calls
 }
 
 // DeleteMessage calls DeleteMessageFunc.
@@ -223,6 +301,50 @@ func (mock *SQSClientMock) DeleteMessageCalls() []struct {
 	return calls
 }
 
+// DeleteMessageBatch calls DeleteMessageBatchFunc.
+func (mock *SQSClientMock) DeleteMessageBatch(contextMoqParam context.Context, deleteMessageBatchInput *sqs.DeleteMessageBatchInput, fns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	callInfo := struct {
+		ContextMoqParam         context.Context
+		DeleteMessageBatchInput *sqs.DeleteMessageBatchInput
+		Fns                     []func(*sqs.Options)
+	}{
+		ContextMoqParam:         contextMoqParam,
+		DeleteMessageBatchInput: deleteMessageBatchInput,
+		Fns:                     fns,
+	}
+	mock.lockDeleteMessageBatch.Lock()
+	mock.calls.DeleteMessageBatch = append(mock.calls.DeleteMessageBatch, callInfo)
+	mock.lockDeleteMessageBatch.Unlock()
+	if mock.DeleteMessageBatchFunc == nil {
+		var (
+			deleteMessageBatchOutputOut *sqs.DeleteMessageBatchOutput
+			errOut                      error
+		)
+		return deleteMessageBatchOutputOut, errOut
+	}
+	return mock.DeleteMessageBatchFunc(contextMoqParam, deleteMessageBatchInput, fns...)
+}
+
+// DeleteMessageBatchCalls gets all the calls that were made to DeleteMessageBatch.
+// Check the length with:
+//
+//	len(mockedSQSClient.DeleteMessageBatchCalls())
+func (mock *SQSClientMock) DeleteMessageBatchCalls() []struct {
+	ContextMoqParam         context.Context
+	DeleteMessageBatchInput *sqs.DeleteMessageBatchInput
+	Fns                     []func(*sqs.Options)
+} {
+	var calls []struct {
+		ContextMoqParam         context.Context
+		DeleteMessageBatchInput *sqs.DeleteMessageBatchInput
+		Fns                     []func(*sqs.Options)
+	}
+	mock.lockDeleteMessageBatch.RLock()
+	calls = mock.calls.DeleteMessageBatch
+	mock.lockDeleteMessageBatch.RUnlock()
+	return calls
+}
+
 // GetQueueUrl calls GetQueueUrlFunc.
 func (mock *SQSClientMock) GetQueueUrl(contextMoqParam context.Context, getQueueUrlInput *sqs.GetQueueUrlInput, fns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
 	callInfo := struct {
@@ -354,3 +476,227 @@ func (mock *SQSClientMock) SendMessageCalls() []struct {
 	mock.lockSendMessage.RUnlock()
 	return calls
 }
+
+// Ensure, that EventBridgeClientMock does implement aws.EventBridgeClient.
+// If this is not the case, regenerate this file with moq.
+var _ aws.EventBridgeClient = &EventBridgeClientMock{}
+
+// EventBridgeClientMock is a mock implementation of aws.EventBridgeClient.
+//
+//	func TestSomethingThatUsesEventBridgeClient(t *testing.T) {
+//
+//		// make and configure a mocked aws.EventBridgeClient
+//		mockedEventBridgeClient := &EventBridgeClientMock{
+//			PutEventsFunc: func(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+//				panic("mock out the PutEvents method")
+//			},
+//		}
+//
+//		// use mockedEventBridgeClient in code that requires aws.EventBridgeClient
+//		// and then make assertions.
+//
+//	}
+type EventBridgeClientMock struct {
+	// PutEventsFunc mocks the PutEvents method.
+	PutEventsFunc func(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// PutEvents holds details about calls to the PutEvents method.
+		PutEvents []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *eventbridge.PutEventsInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*eventbridge.Options)
+		}
+	}
+	lockPutEvents sync.RWMutex
+}
+
+// PutEvents calls PutEventsFunc.
+func (mock *EventBridgeClientMock) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	callInfo := struct {
+		Ctx    context.Context
+		Params *eventbridge.PutEventsInput
+		OptFns []func(*eventbridge.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockPutEvents.Lock()
+	mock.calls.PutEvents = append(mock.calls.PutEvents, callInfo)
+	mock.lockPutEvents.Unlock()
+	if mock.PutEventsFunc == nil {
+		var (
+			putEventsOutputOut *eventbridge.PutEventsOutput
+			errOut             error
+		)
+		return putEventsOutputOut, errOut
+	}
+	return mock.PutEventsFunc(ctx, params, optFns...)
+}
+
+// PutEventsCalls gets all the calls that were made to PutEvents.
+// Check the length with:
+//
+//	len(mockedEventBridgeClient.PutEventsCalls())
+func (mock *EventBridgeClientMock) PutEventsCalls() []struct {
+	Ctx    context.Context
+	Params *eventbridge.PutEventsInput
+	OptFns []func(*eventbridge.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *eventbridge.PutEventsInput
+		OptFns []func(*eventbridge.Options)
+	}
+	mock.lockPutEvents.RLock()
+	calls = mock.calls.PutEvents
+	mock.lockPutEvents.RUnlock()
+	return calls
+}
+
+// Ensure, that KinesisClientMock does implement aws.KinesisClient.
+// If this is not the case, regenerate this file with moq.
+var _ aws.KinesisClient = &KinesisClientMock{}
+
+// KinesisClientMock is a mock implementation of aws.KinesisClient.
+//
+//	func TestSomethingThatUsesKinesisClient(t *testing.T) {
+//
+//		// make and configure a mocked aws.KinesisClient
+//		mockedKinesisClient := &KinesisClientMock{
+//			PutRecordFunc: func(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+//				panic("mock out the PutRecord method")
+//			},
+//			PutRecordsFunc: func(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+//				panic("mock out the PutRecords method")
+//			},
+//		}
+//
+//		// use mockedKinesisClient in code that requires aws.KinesisClient
+//		// and then make assertions.
+//
+//	}
+type KinesisClientMock struct {
+	// PutRecordFunc mocks the PutRecord method.
+	PutRecordFunc func(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+
+	// PutRecordsFunc mocks the PutRecords method.
+	PutRecordsFunc func(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// PutRecord holds details about calls to the PutRecord method.
+		PutRecord []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *kinesis.PutRecordInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*kinesis.Options)
+		}
+		// PutRecords holds details about calls to the PutRecords method.
+		PutRecords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *kinesis.PutRecordsInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*kinesis.Options)
+		}
+	}
+	lockPutRecord  sync.RWMutex
+	lockPutRecords sync.RWMutex
+}
+
+// PutRecord calls PutRecordFunc.
+func (mock *KinesisClientMock) PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	callInfo := struct {
+		Ctx    context.Context
+		Params *kinesis.PutRecordInput
+		OptFns []func(*kinesis.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockPutRecord.Lock()
+	mock.calls.PutRecord = append(mock.calls.PutRecord, callInfo)
+	mock.lockPutRecord.Unlock()
+	if mock.PutRecordFunc == nil {
+		var (
+			putRecordOutputOut *kinesis.PutRecordOutput
+			errOut             error
+		)
+		return putRecordOutputOut, errOut
+	}
+	return mock.PutRecordFunc(ctx, params, optFns...)
+}
+
+// PutRecordCalls gets all the calls that were made to PutRecord.
+// Check the length with:
+//
+//	len(mockedKinesisClient.PutRecordCalls())
+func (mock *KinesisClientMock) PutRecordCalls() []struct {
+	Ctx    context.Context
+	Params *kinesis.PutRecordInput
+	OptFns []func(*kinesis.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *kinesis.PutRecordInput
+		OptFns []func(*kinesis.Options)
+	}
+	mock.lockPutRecord.RLock()
+	calls = mock.calls.PutRecord
+	mock.lockPutRecord.RUnlock()
+	return calls
+}
+
+// PutRecords calls PutRecordsFunc.
+func (mock *KinesisClientMock) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	callInfo := struct {
+		Ctx    context.Context
+		Params *kinesis.PutRecordsInput
+		OptFns []func(*kinesis.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockPutRecords.Lock()
+	mock.calls.PutRecords = append(mock.calls.PutRecords, callInfo)
+	mock.lockPutRecords.Unlock()
+	if mock.PutRecordsFunc == nil {
+		var (
+			putRecordsOutputOut *kinesis.PutRecordsOutput
+			errOut              error
+		)
+		return putRecordsOutputOut, errOut
+	}
+	return mock.PutRecordsFunc(ctx, params, optFns...)
+}
+
+// PutRecordsCalls gets all the calls that were made to PutRecords.
+// Check the length with:
+//
+//	len(mockedKinesisClient.PutRecordsCalls())
+func (mock *KinesisClientMock) PutRecordsCalls() []struct {
+	Ctx    context.Context
+	Params *kinesis.PutRecordsInput
+	OptFns []func(*kinesis.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *kinesis.PutRecordsInput
+		OptFns []func(*kinesis.Options)
+	}
+	mock.lockPutRecords.RLock()
+	calls = mock.calls.PutRecords
+	mock.lockPutRecords.RUnlock()
+	return calls
+}
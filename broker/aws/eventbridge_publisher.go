@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+const (
+	// eventBridgeMaxBatchSize is the max number of entries accepted by a PutEvents request.
+	eventBridgeMaxBatchSize = 10
+	// eventBridgeMaxRequestSize is the max total size in bytes of the entries of a PutEvents request.
+	eventBridgeMaxRequestSize = 256 * 1024
+)
+
+// Errors returned when the event cannot be built from the message.
+var (
+	ErrMissingDetailType = errors.New("missing event detail type")
+	ErrMissingSource     = errors.New("missing event source")
+	ErrEventTooLarge     = errors.New("event exceeds the max request size")
+)
+
+var (
+	_ broker.Broker            = &EventBridgePublisher{}
+	_ messenger.BatchPublisher = &EventBridgePublisher{}
+)
+
+// EventBridgePublisherOption is a function to set options to EventBridgePublisher.
+type EventBridgePublisherOption interface {
+	applyEventBridgePublisher(*EventBridgePublisher)
+}
+
+// OpenEventBridgePublisher creates a new EventBridgePublisher using the default AWS configuration.
+func OpenEventBridgePublisher(
+	ctx context.Context,
+	eventBus string,
+	opts ...EventBridgePublisherOption,
+) (*EventBridgePublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEventBridgePublisher(eventbridge.NewFromConfig(cfg), eventBus, opts...), nil
+}
+
+// NewEventBridgePublisher creates a new EventBridgePublisher with the given EventBridge client
+// and event bus name or ARN, an empty event bus sends the events to the default one.
+func NewEventBridgePublisher(
+	cli EventBridgeClient,
+	eventBus string,
+	opts ...EventBridgePublisherOption,
+) *EventBridgePublisher {
+	p := EventBridgePublisher{
+		cli:      cli,
+		eventBus: eventBus,
+	}
+
+	for _, opt := range opts {
+		opt.applyEventBridgePublisher(&p)
+	}
+
+	return &p
+}
+
+// EventBridgePublisher is an implementation of broker.Broker that puts messages as events in an AWS EventBridge bus.
+// The message payload is sent as the event detail, so it has to be a JSON object, and the event detail type
+// and source are taken from the message metadata or the publisher defaults.
+type EventBridgePublisher struct {
+	// eventbridge service instance where are going to put events
+	cli EventBridgeClient
+	// event bus name or ARN where are going to put events
+	eventBus string
+	// meta property of the message to use as detail type
+	metaDetailTypeKey string
+	// default detail type in case not provided in message metadata
+	defaultDetailType string
+	// meta property of the message to use as source
+	metaSourceKey string
+	// default source in case not provided in message metadata
+	defaultSource string
+}
+
+// Publish puts the provided message as an event in the configured event bus.
+func (p EventBridgePublisher) Publish(ctx context.Context, msg messenger.Message) error {
+	return p.PublishBatch(ctx, []messenger.Message{msg})
+}
+
+// PublishBatch puts the given messages as events in the configured event bus,
+// sending them in requests of up to 10 entries and 256KB. It returns a *messenger.BatchError with the errors of
+// the failed messages, entries rejected by EventBridge are returned as *EntryError, and events bigger than
+// a request as a permanent ErrEventTooLarge.
+func (p EventBridgePublisher) PublishBatch(ctx context.Context, msgs []messenger.Message) error {
+	errs := make(map[string]error)
+	var (
+		batch     []messenger.Message
+		entries   []types.PutEventsRequestEntry
+		batchSize int
+	)
+	for _, msg := range msgs {
+		entry, err := p.entry(msg)
+		if err != nil {
+			errs[msg.ID()] = fmt.Errorf("publishing message %s: %w", msg.ID(), err)
+			continue
+		}
+
+		size := eventBridgeEntrySize(entry)
+		if size > eventBridgeMaxRequestSize {
+			errs[msg.ID()] = messenger.Permanent(fmt.Errorf("publishing message %s: %w", msg.ID(), ErrEventTooLarge))
+			continue
+		}
+
+		if len(entries) == eventBridgeMaxBatchSize || batchSize+size > eventBridgeMaxRequestSize {
+			p.putEvents(ctx, batch, entries, errs)
+			batch, entries, batchSize = nil, nil, 0
+		}
+		batch = append(batch, msg)
+		entries = append(entries, entry)
+		batchSize += size
+	}
+	if len(entries) > 0 {
+		p.putEvents(ctx, batch, entries, errs)
+	}
+
+	if len(errs) > 0 {
+		return &messenger.BatchError{Errors: errs}
+	}
+
+	return nil
+}
+
+// putEvents sends the entries in a single request, recording the error of every failed message.
+func (p EventBridgePublisher) putEvents(
+	ctx context.Context,
+	msgs []messenger.Message,
+	entries []types.PutEventsRequestEntry,
+	errs map[string]error,
+) {
+	out, err := p.cli.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		for _, msg := range msgs {
+			errs[msg.ID()] = fmt.Errorf("publishing message %s: %w", msg.ID(), err)
+		}
+
+		return
+	}
+
+	if out.FailedEntryCount == 0 {
+		return
+	}
+
+	// result entries are returned in the same order as the request ones.
+	for i, res := range out.Entries {
+		if res.ErrorCode == nil || i >= len(msgs) {
+			continue
+		}
+		errs[msgs[i].ID()] = &EntryError{
+			MessageID: msgs[i].ID(),
+			Code:      aws.ToString(res.ErrorCode),
+			Message:   aws.ToString(res.ErrorMessage),
+		}
+	}
+}
+
+// entry transforms the message into an event, resolving its detail type and source.
+func (p EventBridgePublisher) entry(msg messenger.Message) (types.PutEventsRequestEntry, error) {
+	detailType := metaOrDefault(msg, p.metaDetailTypeKey, p.defaultDetailType)
+	if detailType == "" {
+		return types.PutEventsRequestEntry{}, ErrMissingDetailType
+	}
+
+	source := metaOrDefault(msg, p.metaSourceKey, p.defaultSource)
+	if source == "" {
+		return types.PutEventsRequestEntry{}, ErrMissingSource
+	}
+
+	entry := types.PutEventsRequestEntry{
+		Detail:     aws.String(string(msg.Payload())),
+		DetailType: aws.String(detailType),
+		Source:     aws.String(source),
+	}
+	if p.eventBus != "" {
+		entry.EventBusName = aws.String(p.eventBus)
+	}
+
+	return entry, nil
+}
+
+// eventBridgeEntrySize calculates the size of the entry as EventBridge does to check the request limit.
+func eventBridgeEntrySize(entry types.PutEventsRequestEntry) int {
+	size := len(aws.ToString(entry.Source)) + len(aws.ToString(entry.DetailType)) + len(aws.ToString(entry.Detail))
+	if entry.Time != nil {
+		size += 14
+	}
+	for _, r := range entry.Resources {
+		size += len(r)
+	}
+
+	return size
+}
+
+// metaOrDefault tries to get the value from message metadata
+// in case the message does not have the key it returns the default value.
+func metaOrDefault(msg messenger.Message, key, def string) string {
+	if v, ok := msg.Metadata()[key]; ok && v != "" {
+		return v
+	}
+
+	return def
+}
@@ -0,0 +1,339 @@
+package aws_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	publisher "github.com/x4b1/messenger/broker/aws"
+)
+
+const (
+	eventBus      = "test-bus"
+	detailTypeKey = "event_type"
+	sourceKey     = "event_source"
+)
+
+func eventMessage(detailType string) *messenger.GenericMessage {
+	return &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{detailTypeKey: detailType, sourceKey: "orders"},
+		MsgPayload:  []byte(`{"order_id":"29a7556a-ae85-4c1d-8f04-d57ed3122586"}`),
+	}
+}
+
+func TestEventBridge_Publish(t *testing.T) {
+	t.Parallel()
+
+	m := eventMessage("order.created")
+	opts := []publisher.EventBridgePublisherOption{
+		publisher.WithMetaDetailTypeKey(detailTypeKey),
+		publisher.WithMetaSourceKey(sourceKey),
+	}
+
+	t.Run("fails", func(t *testing.T) {
+		t.Parallel()
+
+		ebMock := EventBridgeClientMock{
+			PutEventsFunc: func(context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+				return nil, errAws
+			},
+		}
+
+		pub := publisher.NewEventBridgePublisher(&ebMock, eventBus, opts...)
+
+		require.ErrorIs(t, pub.Publish(context.Background(), m), errAws)
+	})
+
+	t.Run("entry fails", func(t *testing.T) {
+		t.Parallel()
+
+		ebMock := EventBridgeClientMock{
+			PutEventsFunc: func(context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+				return &eventbridge.PutEventsOutput{
+					FailedEntryCount: 1,
+					Entries: []types.PutEventsResultEntry{
+						{ErrorCode: aws.String("InternalFailure"), ErrorMessage: aws.String("internal failure")},
+					},
+				}, nil
+			},
+		}
+
+		pub := publisher.NewEventBridgePublisher(&ebMock, eventBus, opts...)
+
+		var entryErr *publisher.EntryError
+		require.ErrorAs(t, pub.Publish(context.Background(), m), &entryErr)
+		require.Equal(t, &publisher.EntryError{
+			MessageID: m.MsgID,
+			Code:      "InternalFailure",
+			Message:   "internal failure",
+		}, entryErr)
+	})
+
+	for _, tc := range []struct {
+		name          string
+		eventBus      string
+		opts          []publisher.EventBridgePublisherOption
+		expectedEntry types.PutEventsRequestEntry
+	}{
+		{
+			name:     "metadata detail type and source",
+			eventBus: eventBus,
+			opts:     opts,
+			expectedEntry: types.PutEventsRequestEntry{
+				Detail:       aws.String(string(m.MsgPayload)),
+				DetailType:   aws.String("order.created"),
+				Source:       aws.String("orders"),
+				EventBusName: aws.String(eventBus),
+			},
+		},
+		{
+			name: "default detail type and source",
+			opts: []publisher.EventBridgePublisherOption{
+				publisher.WithMetaDetailTypeKey("missing"),
+				publisher.WithDefaultDetailType("default.type"),
+				publisher.WithDefaultSource("default.source"),
+			},
+			expectedEntry: types.PutEventsRequestEntry{
+				Detail:     aws.String(string(m.MsgPayload)),
+				DetailType: aws.String("default.type"),
+				Source:     aws.String("default.source"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			ebMock := EventBridgeClientMock{
+				PutEventsFunc: func(context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+					return &eventbridge.PutEventsOutput{}, nil
+				},
+			}
+
+			pub := publisher.NewEventBridgePublisher(&ebMock, tc.eventBus, tc.opts...)
+			r.NoError(pub.Publish(context.Background(), m))
+
+			r.Len(ebMock.PutEventsCalls(), 1)
+			r.Equal(&eventbridge.PutEventsInput{
+				Entries: []types.PutEventsRequestEntry{tc.expectedEntry},
+			}, ebMock.PutEventsCalls()[0].Params)
+		})
+	}
+
+	for _, tc := range []struct {
+		name        string
+		opts        []publisher.EventBridgePublisherOption
+		expectedErr error
+	}{
+		{
+			name:        "missing detail type",
+			opts:        []publisher.EventBridgePublisherOption{publisher.WithDefaultSource("orders")},
+			expectedErr: publisher.ErrMissingDetailType,
+		},
+		{
+			name:        "missing source",
+			opts:        []publisher.EventBridgePublisherOption{publisher.WithDefaultDetailType("order.created")},
+			expectedErr: publisher.ErrMissingSource,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ebMock := EventBridgeClientMock{}
+			pub := publisher.NewEventBridgePublisher(&ebMock, eventBus, tc.opts...)
+
+			require.ErrorIs(t, pub.Publish(context.Background(), m), tc.expectedErr)
+			require.Empty(t, ebMock.PutEventsCalls())
+		})
+	}
+}
+
+func TestEventBridge_PublishBatch(t *testing.T) {
+	t.Parallel()
+
+	msgs := make([]messenger.Message, 0, 23)
+	for i := range 23 {
+		msgs = append(msgs, eventMessage(fmt.Sprintf("type-%d", i)))
+	}
+	// message without detail type is not sent.
+	invalid := eventMessage("")
+	msgs = append(msgs[:5], append([]messenger.Message{invalid}, msgs[5:]...)...)
+
+	ebMock := EventBridgeClientMock{
+		PutEventsFunc: func(_ context.Context, in *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			out := eventbridge.PutEventsOutput{Entries: make([]types.PutEventsResultEntry, len(in.Entries))}
+			for i, e := range in.Entries {
+				if aws.ToString(e.DetailType) == "type-12" {
+					out.FailedEntryCount++
+					out.Entries[i] = types.PutEventsResultEntry{
+						ErrorCode:    aws.String("ThrottlingException"),
+						ErrorMessage: aws.String("rate exceeded"),
+					}
+
+					continue
+				}
+				out.Entries[i] = types.PutEventsResultEntry{EventId: aws.String(uuid.NewString())}
+			}
+
+			return &out, nil
+		},
+	}
+
+	pub := publisher.NewEventBridgePublisher(
+		&ebMock,
+		eventBus,
+		publisher.WithMetaDetailTypeKey(detailTypeKey),
+		publisher.WithMetaSourceKey(sourceKey),
+	)
+
+	err := pub.PublishBatch(context.Background(), msgs)
+	require.ErrorIs(t, err, publisher.ErrMissingDetailType)
+	require.ErrorContains(t, err, invalid.MsgID)
+
+	var entryErr *publisher.EntryError
+	require.ErrorAs(t, err, &entryErr)
+	require.Equal(t, msgs[13].ID(), entryErr.MessageID)
+	require.Equal(t, "ThrottlingException", entryErr.Code)
+
+	var batchErr *messenger.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 2)
+	require.Contains(t, batchErr.Errors, invalid.MsgID)
+	require.Contains(t, batchErr.Errors, msgs[13].ID())
+
+	calls := ebMock.PutEventsCalls()
+	require.Len(t, calls, 3)
+	require.Len(t, calls[0].Params.Entries, 10)
+	require.Len(t, calls[1].Params.Entries, 10)
+	require.Len(t, calls[2].Params.Entries, 3)
+	require.Equal(t, "type-0", aws.ToString(calls[0].Params.Entries[0].DetailType))
+	require.Equal(t, "type-10", aws.ToString(calls[1].Params.Entries[0].DetailType))
+	require.Equal(t, "type-20", aws.ToString(calls[2].Params.Entries[0].DetailType))
+}
+
+func TestEventBridge_PublishBatchSize(t *testing.T) {
+	t.Parallel()
+
+	// every message takes more than a third of the request size, so only two fit in a request.
+	detail := fmt.Sprintf(`{"data":%q}`, strings.Repeat("a", 100*1024))
+	msgs := make([]messenger.Message, 0, 5)
+	for i := range 5 {
+		msg := eventMessage(fmt.Sprintf("type-%d", i))
+		msg.MsgPayload = []byte(detail)
+		msgs = append(msgs, msg)
+	}
+	tooLarge := eventMessage("too-large")
+	tooLarge.MsgPayload = []byte(fmt.Sprintf(`{"data":%q}`, strings.Repeat("a", 256*1024)))
+	msgs = append(msgs, tooLarge)
+
+	ebMock := EventBridgeClientMock{
+		PutEventsFunc: func(_ context.Context, in *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			return &eventbridge.PutEventsOutput{Entries: make([]types.PutEventsResultEntry, len(in.Entries))}, nil
+		},
+	}
+	pub := publisher.NewEventBridgePublisher(
+		&ebMock,
+		eventBus,
+		publisher.WithMetaDetailTypeKey(detailTypeKey),
+		publisher.WithMetaSourceKey(sourceKey),
+	)
+
+	err := pub.PublishBatch(context.Background(), msgs)
+	require.ErrorIs(t, err, publisher.ErrEventTooLarge)
+	require.True(t, messenger.IsPermanent(err))
+
+	var batchErr *messenger.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	require.Contains(t, batchErr.Errors, tooLarge.MsgID)
+
+	calls := ebMock.PutEventsCalls()
+	require.Len(t, calls, 3)
+	require.Len(t, calls[0].Params.Entries, 2)
+	require.Len(t, calls[1].Params.Entries, 2)
+	require.Len(t, calls[2].Params.Entries, 1)
+}
+
+func TestEventBridge_LocalStack(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cfg := localStack(ctx, t)
+	sqsCli := sqs.NewFromConfig(cfg)
+	ebCli := eventbridge.NewFromConfig(cfg)
+
+	// the events of the test source are routed to a queue where they are received.
+	queue, err := sqsCli.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("events")})
+	require.NoError(t, err)
+	attrs, err := sqsCli.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       queue.QueueUrl,
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	require.NoError(t, err)
+	_, err = ebCli.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:         aws.String("messenger"),
+		EventPattern: aws.String(`{"source":["messenger.test"]}`),
+	})
+	require.NoError(t, err)
+	_, err = ebCli.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule: aws.String("messenger"),
+		Targets: []types.Target{{
+			Id:  aws.String("events"),
+			Arn: aws.String(attrs.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]),
+		}},
+	})
+	require.NoError(t, err)
+
+	msgs := make([]messenger.Message, 0, 3)
+	for i := range cap(msgs) {
+		msgs = append(msgs, &messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{detailTypeKey: "order.created"},
+			MsgPayload:  fmt.Appendf(nil, `{"index":%d}`, i),
+		})
+	}
+
+	pub := publisher.NewEventBridgePublisher(ebCli, "",
+		publisher.WithMetaDetailTypeKey(detailTypeKey),
+		publisher.WithDefaultSource("messenger.test"),
+	)
+	require.NoError(t, pub.Publish(ctx, msgs[0]))
+	require.NoError(t, pub.PublishBatch(ctx, msgs[1:]))
+
+	var received []int
+	for len(received) < len(msgs) {
+		out, err := sqsCli.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            queue.QueueUrl,
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     1,
+		})
+		require.NoError(t, err)
+		for _, m := range out.Messages {
+			var event struct {
+				Source     string `json:"source"`
+				DetailType string `json:"detail-type"`
+				Detail     struct {
+					Index int `json:"index"`
+				} `json:"detail"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(aws.ToString(m.Body)), &event))
+			require.Equal(t, "messenger.test", event.Source)
+			require.Equal(t, "order.created", event.DetailType)
+			received = append(received, event.Detail.Index)
+		}
+	}
+	require.ElementsMatch(t, []int{0, 1, 2}, received)
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/internal/payload"
+)
+
+// KinesisEnvelope encodes the message into the record data, as Kinesis records do not have attributes
+// where to send the message id and metadata.
+type KinesisEnvelope func(msg messenger.Message) ([]byte, error)
+
+// kinesisRecord is the record data sent by KinesisJSONEnvelope.
+type kinesisRecord struct {
+	ID              string             `json:"id"`
+	Metadata        messenger.Metadata `json:"metadata"`
+	PayloadEncoding string             `json:"payload_encoding"`
+	Payload         json.RawMessage    `json:"payload"`
+	At              time.Time          `json:"at"`
+}
+
+// KinesisJSONEnvelope encodes the message id, metadata, payload and creation time as a JSON object.
+// The payload_encoding field tells how the payload is encoded so consumers recover the original bytes:
+// "json" when the payload is compact JSON embedded as it is, otherwise "base64" with the payload as a base64 string.
+func KinesisJSONEnvelope(msg messenger.Message) ([]byte, error) {
+	encoding, value, err := payload.Encode(msg.Payload())
+	if err != nil {
+		return nil, err
+	}
+
+	return payload.Marshal(kinesisRecord{
+		ID:              msg.ID(),
+		Metadata:        msg.Metadata(),
+		PayloadEncoding: encoding,
+		Payload:         value,
+		At:              msg.At(),
+	})
+}
+
+// KinesisRawEnvelope sends the message payload as the record data, discarding the message id and metadata.
+func KinesisRawEnvelope(msg messenger.Message) ([]byte, error) {
+	return msg.Payload(), nil
+}
@@ -0,0 +1,204 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+const (
+	// kinesisMaxBatchSize is the max number of records accepted by a PutRecords request.
+	kinesisMaxBatchSize = 500
+	// kinesisMaxRecordSize is the max size in bytes of a record data and partition key.
+	kinesisMaxRecordSize = 1 << 20
+	// kinesisMaxRequestSize is the max total size in bytes of the records of a PutRecords request.
+	kinesisMaxRequestSize = 5 << 20
+)
+
+// ErrRecordTooLarge is returned when the record data and partition key exceed the max record size.
+var ErrRecordTooLarge = errors.New("record exceeds the max record size")
+
+var (
+	_ broker.Broker            = &KinesisPublisher{}
+	_ messenger.BatchPublisher = &KinesisPublisher{}
+)
+
+// KinesisPublisherOption is a function to set options to KinesisPublisher.
+type KinesisPublisherOption interface {
+	applyKinesisPublisher(*KinesisPublisher)
+}
+
+// OpenKinesisPublisher creates a new KinesisPublisher using the default AWS configuration.
+func OpenKinesisPublisher(
+	ctx context.Context,
+	stream string,
+	opts ...KinesisPublisherOption,
+) (*KinesisPublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKinesisPublisher(kinesis.NewFromConfig(cfg), stream, opts...), nil
+}
+
+// NewKinesisPublisher creates a new KinesisPublisher with the given Kinesis client and stream name or ARN.
+func NewKinesisPublisher(cli KinesisClient, stream string, opts ...KinesisPublisherOption) *KinesisPublisher {
+	p := KinesisPublisher{
+		cli:      cli,
+		envelope: KinesisJSONEnvelope,
+	}
+	if arn.IsARN(stream) {
+		p.streamARN = aws.String(stream)
+	} else {
+		p.streamName = aws.String(stream)
+	}
+
+	for _, opt := range opts {
+		opt.applyKinesisPublisher(&p)
+	}
+
+	return &p
+}
+
+// KinesisPublisher is an implementation of broker.Broker that puts messages as records in an AWS Kinesis data stream.
+// Records are encoded with the configured envelope, by default KinesisJSONEnvelope, and partitioned by
+// the ordering key, so messages with the same key are kept in order within the same shard.
+type KinesisPublisher struct {
+	// kinesis service instance where are going to put records
+	cli KinesisClient
+	// stream where are going to put records, given by name or ARN.
+	streamName *string
+	streamARN  *string
+	// meta property of the message to use as partition key
+	metaOrdKey string
+	// default partition key in case not provided in message metadata
+	defaultOrdKey string
+	// encodes the message into the record data
+	envelope KinesisEnvelope
+}
+
+// Publish puts the provided message as a record in the configured stream,
+// records bigger than 1MiB are rejected with a permanent ErrRecordTooLarge.
+func (p KinesisPublisher) Publish(ctx context.Context, msg messenger.Message) error {
+	data, err := p.envelope(msg)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+
+	key := p.partitionKey(msg)
+	if len(data)+len(key) > kinesisMaxRecordSize {
+		return messenger.Permanent(fmt.Errorf("publishing message: %w", ErrRecordTooLarge))
+	}
+
+	_, err = p.cli.PutRecord(ctx, &kinesis.PutRecordInput{
+		Data:         data,
+		PartitionKey: aws.String(key),
+		StreamName:   p.streamName,
+		StreamARN:    p.streamARN,
+	})
+	if err != nil {
+		return fmt.Errorf("publishing message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishBatch puts the given messages as records in the configured stream, sending them in requests
+// of up to 500 records and 5MiB. It returns a *messenger.BatchError with the errors of the failed messages,
+// records rejected by Kinesis are returned as *EntryError, and records bigger than 1MiB as a permanent
+// ErrRecordTooLarge.
+func (p KinesisPublisher) PublishBatch(ctx context.Context, msgs []messenger.Message) error {
+	errs := make(map[string]error)
+	var (
+		batch     []messenger.Message
+		records   []types.PutRecordsRequestEntry
+		batchSize int
+	)
+	for _, msg := range msgs {
+		data, err := p.envelope(msg)
+		if err != nil {
+			errs[msg.ID()] = fmt.Errorf("publishing message %s: encoding message: %w", msg.ID(), err)
+			continue
+		}
+
+		key := p.partitionKey(msg)
+		size := len(data) + len(key)
+		if size > kinesisMaxRecordSize {
+			errs[msg.ID()] = messenger.Permanent(fmt.Errorf("publishing message %s: %w", msg.ID(), ErrRecordTooLarge))
+			continue
+		}
+
+		if len(records) == kinesisMaxBatchSize || batchSize+size > kinesisMaxRequestSize {
+			p.putRecords(ctx, batch, records, errs)
+			batch, records, batchSize = nil, nil, 0
+		}
+		batch = append(batch, msg)
+		records = append(records, types.PutRecordsRequestEntry{Data: data, PartitionKey: aws.String(key)})
+		batchSize += size
+	}
+	if len(records) > 0 {
+		p.putRecords(ctx, batch, records, errs)
+	}
+
+	if len(errs) > 0 {
+		return &messenger.BatchError{Errors: errs}
+	}
+
+	return nil
+}
+
+// putRecords sends the records in a single request, recording the error of every failed message.
+func (p KinesisPublisher) putRecords(
+	ctx context.Context,
+	msgs []messenger.Message,
+	records []types.PutRecordsRequestEntry,
+	errs map[string]error,
+) {
+	out, err := p.cli.PutRecords(ctx, &kinesis.PutRecordsInput{
+		Records:    records,
+		StreamName: p.streamName,
+		StreamARN:  p.streamARN,
+	})
+	if err != nil {
+		for _, msg := range msgs {
+			errs[msg.ID()] = fmt.Errorf("publishing message %s: %w", msg.ID(), err)
+		}
+
+		return
+	}
+
+	if aws.ToInt32(out.FailedRecordCount) == 0 {
+		return
+	}
+
+	// result records are returned in the same order as the request ones.
+	for i, res := range out.Records {
+		if res.ErrorCode == nil || i >= len(msgs) {
+			continue
+		}
+		errs[msgs[i].ID()] = &EntryError{
+			MessageID: msgs[i].ID(),
+			Code:      aws.ToString(res.ErrorCode),
+			Message:   aws.ToString(res.ErrorMessage),
+		}
+	}
+}
+
+// partitionKey tries to get the partition key from message metadata
+// in case the message does not have the key it defaults to Publisher setup or the message id.
+func (p KinesisPublisher) partitionKey(msg messenger.Message) string {
+	if key := metaOrDefault(msg, p.metaOrdKey, p.defaultOrdKey); key != "" {
+		return key
+	}
+
+	return msg.ID()
+}
@@ -0,0 +1,371 @@
+package aws_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	publisher "github.com/x4b1/messenger/broker/aws"
+)
+
+const (
+	streamName = "test-stream"
+	streamARN  = "arn:aws:kinesis:eu-west-1:000000000000:stream/test-stream"
+)
+
+func TestKinesis_Publish(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       "0a4d5a8e-5d0c-4f4b-a3d5-2b0b8b8d1e6f",
+		MsgMetadata: map[string]string{metaKey: orderingValue},
+		MsgPayload:  []byte(`{"name":"some message"}`),
+		MsgAt:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	jsonRecord := []byte(`{"id":"0a4d5a8e-5d0c-4f4b-a3d5-2b0b8b8d1e6f","metadata":{"meta-key":"value-1"},` +
+		`"payload_encoding":"json","payload":{"name":"some message"},"at":"2025-01-02T03:04:05Z"}`)
+
+	t.Run("fails", func(t *testing.T) {
+		t.Parallel()
+
+		kMock := KinesisClientMock{
+			PutRecordFunc: func(context.Context, *kinesis.PutRecordInput, ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+				return nil, errAws
+			},
+		}
+
+		require.ErrorIs(t, publisher.NewKinesisPublisher(&kMock, streamName).Publish(context.Background(), m), errAws)
+	})
+
+	t.Run("record too large", func(t *testing.T) {
+		t.Parallel()
+
+		kMock := KinesisClientMock{}
+		pub := publisher.NewKinesisPublisher(&kMock, streamName, publisher.WithKinesisEnvelope(publisher.KinesisRawEnvelope))
+
+		err := pub.Publish(context.Background(), &messenger.GenericMessage{
+			MsgID:      "id",
+			MsgPayload: bytes.Repeat([]byte("a"), 1<<20),
+		})
+		require.ErrorIs(t, err, publisher.ErrRecordTooLarge)
+		require.True(t, messenger.IsPermanent(err))
+		require.Empty(t, kMock.PutRecordCalls())
+	})
+
+	t.Run("envelope fails", func(t *testing.T) {
+		t.Parallel()
+
+		errEnvelope := errors.New("envelope error")
+		kMock := KinesisClientMock{}
+		pub := publisher.NewKinesisPublisher(&kMock, streamName, publisher.WithKinesisEnvelope(
+			func(messenger.Message) ([]byte, error) { return nil, errEnvelope },
+		))
+
+		require.ErrorIs(t, pub.Publish(context.Background(), m), errEnvelope)
+		require.Empty(t, kMock.PutRecordCalls())
+	})
+
+	for _, tc := range []struct {
+		name          string
+		stream        string
+		opts          []publisher.KinesisPublisherOption
+		expectedInput *kinesis.PutRecordInput
+	}{
+		{
+			name:   "message id partition key",
+			stream: streamName,
+			expectedInput: &kinesis.PutRecordInput{
+				Data:         jsonRecord,
+				PartitionKey: aws.String(m.MsgID),
+				StreamName:   aws.String(streamName),
+			},
+		},
+		{
+			name:   "default partition key",
+			stream: streamName,
+			opts:   []publisher.KinesisPublisherOption{publisher.WithDefaultOrderingKey(defaultOrdKey)},
+			expectedInput: &kinesis.PutRecordInput{
+				Data:         jsonRecord,
+				PartitionKey: aws.String(defaultOrdKey),
+				StreamName:   aws.String(streamName),
+			},
+		},
+		{
+			name:   "metadata partition key",
+			stream: streamARN,
+			opts: []publisher.KinesisPublisherOption{
+				publisher.WithDefaultOrderingKey(defaultOrdKey),
+				publisher.WithMetaOrderingKey(metaKey),
+			},
+			expectedInput: &kinesis.PutRecordInput{
+				Data:         jsonRecord,
+				PartitionKey: aws.String(orderingValue),
+				StreamARN:    aws.String(streamARN),
+			},
+		},
+		{
+			name:   "raw envelope",
+			stream: streamName,
+			opts:   []publisher.KinesisPublisherOption{publisher.WithKinesisEnvelope(publisher.KinesisRawEnvelope)},
+			expectedInput: &kinesis.PutRecordInput{
+				Data:         m.MsgPayload,
+				PartitionKey: aws.String(m.MsgID),
+				StreamName:   aws.String(streamName),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			kMock := KinesisClientMock{}
+
+			pub := publisher.NewKinesisPublisher(&kMock, tc.stream, tc.opts...)
+			r.NoError(pub.Publish(context.Background(), m))
+
+			r.Len(kMock.PutRecordCalls(), 1)
+			params := kMock.PutRecordCalls()[0].Params
+			r.JSONEq(string(tc.expectedInput.Data), string(params.Data))
+			params.Data = tc.expectedInput.Data
+			r.Equal(tc.expectedInput, params)
+		})
+	}
+}
+
+func TestKinesisJSONEnvelope(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{
+			name:     "json object",
+			payload:  `{"name":"<some> & message"}`,
+			expected: `"payload_encoding":"json","payload":{"name":"<some> & message"}`,
+		},
+		{
+			name:     "json string",
+			payload:  `"some message"`,
+			expected: `"payload_encoding":"json","payload":"some message"`,
+		},
+		{
+			name:     "formatted json",
+			payload:  `{ "name": "some message" }`,
+			expected: `"payload_encoding":"base64","payload":"eyAibmFtZSI6ICJzb21lIG1lc3NhZ2UiIH0="`,
+		},
+		{
+			name:     "not json",
+			payload:  "not json",
+			expected: `"payload_encoding":"base64","payload":"bm90IGpzb24="`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := publisher.KinesisJSONEnvelope(&messenger.GenericMessage{
+				MsgID:       "id",
+				MsgMetadata: map[string]string{"key": "value"},
+				MsgPayload:  []byte(tc.payload),
+				MsgAt:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			})
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				`{"id":"id","metadata":{"key":"value"},`+tc.expected+`,"at":"2025-01-02T03:04:05Z"}`,
+				string(data),
+			)
+		})
+	}
+}
+
+func TestKinesis_PublishBatch(t *testing.T) {
+	t.Parallel()
+
+	msgs := make([]messenger.Message, 0, 1001)
+	for i := range 1001 {
+		msgs = append(msgs, &messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{metaKey: fmt.Sprint(i)},
+			MsgPayload:  []byte(fmt.Sprint(i)),
+		})
+	}
+
+	kMock := KinesisClientMock{
+		PutRecordsFunc: func(_ context.Context, in *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			out := kinesis.PutRecordsOutput{
+				FailedRecordCount: aws.Int32(0),
+				Records:           make([]types.PutRecordsResultEntry, len(in.Records)),
+			}
+			for i, rec := range in.Records {
+				if aws.ToString(rec.PartitionKey) == "700" {
+					out.FailedRecordCount = aws.Int32(1)
+					out.Records[i] = types.PutRecordsResultEntry{
+						ErrorCode:    aws.String("ProvisionedThroughputExceededException"),
+						ErrorMessage: aws.String("rate exceeded for shard"),
+					}
+
+					continue
+				}
+				out.Records[i] = types.PutRecordsResultEntry{SequenceNumber: aws.String("1"), ShardId: aws.String("0")}
+			}
+
+			return &out, nil
+		},
+	}
+
+	pub := publisher.NewKinesisPublisher(
+		&kMock,
+		streamName,
+		publisher.WithMetaOrderingKey(metaKey),
+		publisher.WithKinesisEnvelope(publisher.KinesisRawEnvelope),
+	)
+
+	err := pub.PublishBatch(context.Background(), msgs)
+	var batchErr *messenger.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, map[string]error{
+		msgs[700].ID(): &publisher.EntryError{
+			MessageID: msgs[700].ID(),
+			Code:      "ProvisionedThroughputExceededException",
+			Message:   "rate exceeded for shard",
+		},
+	}, batchErr.Errors)
+
+	calls := kMock.PutRecordsCalls()
+	require.Len(t, calls, 3)
+	require.Len(t, calls[0].Params.Records, 500)
+	require.Len(t, calls[1].Params.Records, 500)
+	require.Len(t, calls[2].Params.Records, 1)
+	require.Equal(t, aws.String(streamName), calls[0].Params.StreamName)
+	require.Equal(t, []byte("1000"), calls[2].Params.Records[0].Data)
+
+	t.Run("fails", func(t *testing.T) {
+		t.Parallel()
+
+		kMock := KinesisClientMock{
+			PutRecordsFunc: func(context.Context, *kinesis.PutRecordsInput, ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+				return nil, errAws
+			},
+		}
+
+		err := publisher.NewKinesisPublisher(&kMock, streamName).PublishBatch(context.Background(), msgs[:2])
+		require.ErrorIs(t, err, errAws)
+		var batchErr *messenger.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		require.Contains(t, batchErr.Errors, msgs[0].ID())
+		require.Contains(t, batchErr.Errors, msgs[1].ID())
+	})
+}
+
+func TestKinesis_PublishBatchSize(t *testing.T) {
+	t.Parallel()
+
+	// every record takes more than a sixth of the request size, so only five fit in a request.
+	msgs := make([]messenger.Message, 0, 8)
+	for i := range 7 {
+		msgs = append(msgs, &messenger.GenericMessage{
+			MsgID:      fmt.Sprint(i),
+			MsgPayload: bytes.Repeat([]byte("a"), 900<<10),
+		})
+	}
+	tooLarge := &messenger.GenericMessage{MsgID: "too-large", MsgPayload: bytes.Repeat([]byte("a"), 1<<20)}
+	msgs = append(msgs, tooLarge)
+
+	kMock := KinesisClientMock{
+		PutRecordsFunc: func(_ context.Context, in *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			return &kinesis.PutRecordsOutput{Records: make([]types.PutRecordsResultEntry, len(in.Records))}, nil
+		},
+	}
+	pub := publisher.NewKinesisPublisher(&kMock, streamName, publisher.WithKinesisEnvelope(publisher.KinesisRawEnvelope))
+
+	err := pub.PublishBatch(context.Background(), msgs)
+	require.ErrorIs(t, err, publisher.ErrRecordTooLarge)
+	require.True(t, messenger.IsPermanent(err))
+
+	var batchErr *messenger.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	require.Contains(t, batchErr.Errors, tooLarge.MsgID)
+
+	calls := kMock.PutRecordsCalls()
+	require.Len(t, calls, 2)
+	require.Len(t, calls[0].Params.Records, 5)
+	require.Len(t, calls[1].Params.Records, 2)
+}
+
+func TestKinesis_LocalStack(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cli := kinesis.NewFromConfig(localStack(ctx, t))
+	_, err := cli.CreateStream(ctx, &kinesis.CreateStreamInput{
+		StreamName: aws.String(streamName),
+		ShardCount: aws.Int32(1),
+	})
+	require.NoError(t, err)
+	require.NoError(t, kinesis.NewStreamExistsWaiter(cli).Wait(ctx,
+		&kinesis.DescribeStreamInput{StreamName: aws.String(streamName)},
+		time.Minute,
+	))
+
+	msgs := []messenger.Message{
+		&messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{metaKey: orderingValue},
+			MsgPayload:  []byte(`{"name":"some message"}`),
+		},
+		&messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{metaKey: orderingValue},
+			MsgPayload:  []byte("not json"),
+		},
+		&messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{metaKey: orderingValue},
+			MsgPayload:  []byte(`{"name":"other message"}`),
+		},
+	}
+
+	pub := publisher.NewKinesisPublisher(cli, streamName, publisher.WithMetaOrderingKey(metaKey))
+	require.NoError(t, pub.Publish(ctx, msgs[0]))
+	require.NoError(t, pub.PublishBatch(ctx, msgs[1:]))
+
+	shards, err := cli.ListShards(ctx, &kinesis.ListShardsInput{StreamName: aws.String(streamName)})
+	require.NoError(t, err)
+	require.Len(t, shards.Shards, 1)
+	it, err := cli.GetShardIterator(ctx, &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(streamName),
+		ShardId:           shards.Shards[0].ShardId,
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	})
+	require.NoError(t, err)
+
+	var records []types.Record
+	for iterator := it.ShardIterator; len(records) < len(msgs); {
+		out, err := cli.GetRecords(ctx, &kinesis.GetRecordsInput{ShardIterator: iterator})
+		require.NoError(t, err)
+		records = append(records, out.Records...)
+		iterator = out.NextShardIterator
+	}
+
+	require.Len(t, records, len(msgs))
+	for i, msg := range msgs {
+		require.Equal(t, orderingValue, aws.ToString(records[i].PartitionKey))
+
+		expected, err := publisher.KinesisJSONEnvelope(msg)
+		require.NoError(t, err)
+		require.Equal(t, expected, records[i].Data)
+	}
+}
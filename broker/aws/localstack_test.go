@@ -0,0 +1,27 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/x4b1/messenger/internal/testhelpers"
+)
+
+// localStack starts a localstack container for the test and returns the aws config to connect to it,
+// the test is skipped if docker is not available.
+func localStack(ctx context.Context, t *testing.T) aws.Config {
+	t.Helper()
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	cfg, container, err := testhelpers.CreateLocalStackContainer(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = container.Terminate(context.Background())
+	})
+
+	return cfg
+}
@@ -1,11 +1,14 @@
 package aws
 
-// WithDefaultOrderingKey returns an option to configure the default ordering key for SNS or SQS publishers.
+import "time"
+
+// WithDefaultOrderingKey returns an option to configure the default ordering key for SNS or SQS publishers,
+// or the default partition key for Kinesis publishers.
 func WithDefaultOrderingKey(key string) DefaultOrderingKeyOption {
 	return DefaultOrderingKeyOption(key)
 }
 
-// DefaultOrderingKeyOption is an option type for setting the default ordering key for SNS or SQS publishers.
+// DefaultOrderingKeyOption is an option type for setting the default ordering key for SNS, SQS or Kinesis publishers.
 type DefaultOrderingKeyOption string
 
 func (d DefaultOrderingKeyOption) applySNSPublisher(p *SNSPublisher) {
@@ -16,12 +19,17 @@ func (d DefaultOrderingKeyOption) applySQSPublisher(p *SQSPublisher) {
 	p.defaultOrdKey = string(d)
 }
 
-// WithMetaOrderingKey returns an option to configure the metadata ordering key for SNS or SQS publishers.
+func (d DefaultOrderingKeyOption) applyKinesisPublisher(p *KinesisPublisher) {
+	p.defaultOrdKey = string(d)
+}
+
+// WithMetaOrderingKey returns an option to configure the metadata ordering key for SNS or SQS publishers,
+// or the metadata partition key for Kinesis publishers.
 func WithMetaOrderingKey(key string) MetaOrderingKeyOption {
 	return MetaOrderingKeyOption(key)
 }
 
-// MetaOrderingKeyOption is an option type for setting the metadata ordering key for SNS or SQS publishers.
+// MetaOrderingKeyOption is an option type for setting the metadata ordering key for SNS, SQS or Kinesis publishers.
 type MetaOrderingKeyOption string
 
 func (m MetaOrderingKeyOption) applySNSPublisher(p *SNSPublisher) {
@@ -32,6 +40,10 @@ func (m MetaOrderingKeyOption) applySQSPublisher(p *SQSPublisher) {
 	p.metaOrdKey = string(m)
 }
 
+func (m MetaOrderingKeyOption) applyKinesisPublisher(p *KinesisPublisher) {
+	p.metaOrdKey = string(m)
+}
+
 // WithMessageIDKey returns an option to configure the message ID key for SNS or SQS publishers, or SQS subscribers.
 func WithMessageIDKey(key string) MessageIDKeyOption {
 	return MessageIDKeyOption(key)
@@ -53,6 +65,24 @@ func (m MessageIDKeyOption) applySQSSubscriber(p *SQSSubscriber) {
 	p.msgIDKey = string(m)
 }
 
+// WithQueueOwner returns an option to configure the AWS account that owns the queues given by name
+// for SQS publishers or subscribers, allowing to use cross-account queues. Queues given by ARN use
+// the account in the ARN instead.
+func WithQueueOwner(accountID string) QueueOwnerOption {
+	return QueueOwnerOption(accountID)
+}
+
+// QueueOwnerOption is an option type for setting the queues owner account for SQS publishers or subscribers.
+type QueueOwnerOption string
+
+func (q QueueOwnerOption) applySQSPublisher(p *SQSPublisher) {
+	p.queueOwner = string(q)
+}
+
+func (q QueueOwnerOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.queueOwner = string(q)
+}
+
 // WithFifoQueue returns an option to enable or disable FIFO queue usage for SNS or SQS publishers.
 func WithFifoQueue(fifo bool) FifoQueueOption {
 	return FifoQueueOption(fifo)
@@ -92,3 +122,253 @@ type MaxMessagesOption int
 func (m MaxMessagesOption) applySQSSubscriber(p *SQSSubscriber) {
 	p.maxMessages = int(m)
 }
+
+// WithConcurrency returns an option to set the number of workers processing messages
+// of each subscription at the same time for SQS subscribers.
+func WithConcurrency(workers int) ConcurrencyOption {
+	return ConcurrencyOption(workers)
+}
+
+// ConcurrencyOption is an option type for setting the number of workers per subscription for SQS subscribers.
+type ConcurrencyOption int
+
+func (c ConcurrencyOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.concurrency = int(c)
+}
+
+// WithMaxInFlight returns an option to set the maximum number of received messages not yet processed
+// of each subscription for SQS subscribers. The subscriber stops receiving while the limit is reached.
+// It defaults to the greater of the concurrency and the number of pollers multiplied by the max messages.
+func WithMaxInFlight(msgs int) MaxInFlightOption {
+	return MaxInFlightOption(msgs)
+}
+
+// MaxInFlightOption is an option type for setting the maximum number of in flight messages
+// per subscription for SQS subscribers.
+type MaxInFlightOption int
+
+func (m MaxInFlightOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.maxInFlight = int(m)
+}
+
+// WithVisibilityHeartbeat returns an option to extend, every interval, the visibility timeout
+// of the messages being processed by SQS subscribers, preventing the queue from delivering them
+// again while the handler is still running.
+func WithVisibilityHeartbeat(interval, visibilityTimeout time.Duration) VisibilityHeartbeatOption {
+	return VisibilityHeartbeatOption{interval, visibilityTimeout}
+}
+
+// VisibilityHeartbeatOption is an option type for setting the visibility timeout heartbeat for SQS subscribers.
+type VisibilityHeartbeatOption struct {
+	interval          time.Duration
+	visibilityTimeout time.Duration
+}
+
+func (v VisibilityHeartbeatOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.heartbeat = v
+}
+
+// WithBackoff returns an option to delay the redelivery of failed messages for SQS subscribers,
+// setting their visibility timeout to an exponential backoff based on the times they have been received,
+// starting with the initial duration and up to the max duration.
+func WithBackoff(initial, maxBackoff time.Duration) BackoffOption {
+	return BackoffOption{initial, maxBackoff}
+}
+
+// BackoffOption is an option type for setting the failed messages backoff for SQS subscribers.
+type BackoffOption struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (b BackoffOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.backoff = b
+}
+
+// WithDeadLetterQueue returns an option to forward the failed messages to the given dead letter queue
+// for SQS subscribers, given by name, ARN or url, once they have been received maxReceives times or the handler returns a permanent error.
+// A maxReceives below 1 forwards the messages on their first failure.
+// The failure reason, truncated to 1024 bytes, is added to the message attributes under FailureReasonKey,
+// or packed in the overflow attribute if the message already has the max number of attributes.
+func WithDeadLetterQueue(queue string, maxReceives int) DeadLetterQueueOption {
+	return DeadLetterQueueOption{queue, maxReceives}
+}
+
+// DeadLetterQueueOption is an option type for setting the dead letter queue for SQS subscribers.
+type DeadLetterQueueOption struct {
+	queue       string
+	maxReceives int
+}
+
+func (d DeadLetterQueueOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.deadLetter = d
+}
+
+// WithSNSEnvelope returns an option to unwrap the SNS notification envelope for SQS subscribers,
+// for queues subscribed to SNS topics without raw message delivery. When enabled, messages with an
+// SNS notification body are delivered with the original payload, metadata and message id.
+func WithSNSEnvelope(unwrap bool) SNSEnvelopeOption {
+	return SNSEnvelopeOption(unwrap)
+}
+
+// SNSEnvelopeOption is an option type for enabling or disabling the SNS envelope unwrapping for SQS subscribers.
+type SNSEnvelopeOption bool
+
+func (u SNSEnvelopeOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.unwrapSNS = bool(u)
+}
+
+// WithSystemAttributes returns an option to expose the message system attributes as metadata for SQS subscribers,
+// under the keys prefixed with SQSMetadataPrefix.
+func WithSystemAttributes(enabled bool) SystemAttributesOption {
+	return SystemAttributesOption(enabled)
+}
+
+// SystemAttributesOption is an option type for exposing the message system attributes for SQS subscribers.
+type SystemAttributesOption bool
+
+func (a SystemAttributesOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.systemAttributes = bool(a)
+}
+
+// WithDeleteBatch returns an option to delete the processed messages in batches for SQS subscribers,
+// the messages are deleted once there are 10 pending or the given window elapses since the first one.
+func WithDeleteBatch(window time.Duration) DeleteBatchOption {
+	return DeleteBatchOption(window)
+}
+
+// DeleteBatchOption is an option type for setting the batched deletes window for SQS subscribers.
+type DeleteBatchOption time.Duration
+
+func (d DeleteBatchOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.deleteBatchWindow = time.Duration(d)
+}
+
+// WithVisibilityTimeout returns an option to set the visibility timeout of the received messages
+// for SQS subscribers, overriding the queue default.
+func WithVisibilityTimeout(d time.Duration) VisibilityTimeoutOption {
+	return VisibilityTimeoutOption(d)
+}
+
+// VisibilityTimeoutOption is an option type for setting the receive visibility timeout for SQS subscribers.
+type VisibilityTimeoutOption time.Duration
+
+func (v VisibilityTimeoutOption) applySQSSubscriber(p *SQSSubscriber) {
+	p.visibilityTimeout = time.Duration(v)
+}
+
+// WithPollers returns an option to set the number of parallel long polling loops per subscription
+// for SQS subscribers.
+func WithPollers(pollers int) PollersOption {
+	return PollersOption(pollers)
+}
+
+// PollersOption is an option type for setting the number of pollers per subscription for SQS subscribers.
+type PollersOption int
+
+func (p PollersOption) applySQSSubscriber(s *SQSSubscriber) {
+	s.pollers = int(p)
+}
+
+// WithMetaDetailTypeKey returns an option to configure the metadata key of the event detail type for EventBridge publishers.
+func WithMetaDetailTypeKey(key string) MetaDetailTypeKeyOption {
+	return MetaDetailTypeKeyOption(key)
+}
+
+// MetaDetailTypeKeyOption is an option type for setting the metadata detail type key for EventBridge publishers.
+type MetaDetailTypeKeyOption string
+
+func (m MetaDetailTypeKeyOption) applyEventBridgePublisher(p *EventBridgePublisher) {
+	p.metaDetailTypeKey = string(m)
+}
+
+// WithDefaultDetailType returns an option to configure the default event detail type for EventBridge publishers.
+func WithDefaultDetailType(detailType string) DefaultDetailTypeOption {
+	return DefaultDetailTypeOption(detailType)
+}
+
+// DefaultDetailTypeOption is an option type for setting the default detail type for EventBridge publishers.
+type DefaultDetailTypeOption string
+
+func (d DefaultDetailTypeOption) applyEventBridgePublisher(p *EventBridgePublisher) {
+	p.defaultDetailType = string(d)
+}
+
+// WithMetaSourceKey returns an option to configure the metadata key of the event source for EventBridge publishers.
+func WithMetaSourceKey(key string) MetaSourceKeyOption {
+	return MetaSourceKeyOption(key)
+}
+
+// MetaSourceKeyOption is an option type for setting the metadata source key for EventBridge publishers.
+type MetaSourceKeyOption string
+
+func (m MetaSourceKeyOption) applyEventBridgePublisher(p *EventBridgePublisher) {
+	p.metaSourceKey = string(m)
+}
+
+// WithDefaultSource returns an option to configure the default event source for EventBridge publishers.
+func WithDefaultSource(source string) DefaultSourceOption {
+	return DefaultSourceOption(source)
+}
+
+// DefaultSourceOption is an option type for setting the default source for EventBridge publishers.
+type DefaultSourceOption string
+
+func (d DefaultSourceOption) applyEventBridgePublisher(p *EventBridgePublisher) {
+	p.defaultSource = string(d)
+}
+
+// WithKinesisEnvelope returns an option to configure how messages are encoded into the records data
+// for Kinesis publishers, by default KinesisJSONEnvelope.
+func WithKinesisEnvelope(envelope KinesisEnvelope) KinesisEnvelopeOption {
+	return KinesisEnvelopeOption(envelope)
+}
+
+// KinesisEnvelopeOption is an option type for setting the records envelope for Kinesis publishers.
+type KinesisEnvelopeOption KinesisEnvelope
+
+func (k KinesisEnvelopeOption) applyKinesisPublisher(p *KinesisPublisher) {
+	p.envelope = KinesisEnvelope(k)
+}
+
+// WithAttributeTypes returns an option to configure the data type of the message attributes
+// by metadata key for SNS or SQS publishers, the rest of metadata is sent as String.
+// Number attributes allow numeric conditions in SNS filter policies.
+func WithAttributeTypes(types map[string]AttributeType) AttributeTypesOption {
+	return AttributeTypesOption(types)
+}
+
+// AttributeTypesOption is an option type for setting the message attributes data types for SNS or SQS publishers.
+type AttributeTypesOption map[string]AttributeType
+
+func (a AttributeTypesOption) applySNSPublisher(p *SNSPublisher) {
+	p.attributes.types = a
+}
+
+func (a AttributeTypesOption) applySQSPublisher(p *SQSPublisher) {
+	p.attributes.types = a
+}
+
+// WithOverflowAttribute returns an option to pack the metadata exceeding the limit of 10 message attributes
+// as JSON in the given attribute for SNS or SQS publishers, instead of failing with ErrTooManyAttributes.
+// The message id and typed attributes are kept as attributes first. SQS subscribers unpack the attribute
+// back into the message metadata.
+func WithOverflowAttribute(key string) OverflowAttributeOption {
+	return OverflowAttributeOption(key)
+}
+
+// OverflowAttributeOption is an option type for setting the overflow attribute for SNS or SQS publishers,
+// or SQS subscribers.
+type OverflowAttributeOption string
+
+func (o OverflowAttributeOption) applySNSPublisher(p *SNSPublisher) {
+	p.attributes.overflowKey = string(o)
+}
+
+func (o OverflowAttributeOption) applySQSPublisher(p *SQSPublisher) {
+	p.attributes.overflowKey = string(o)
+}
+
+func (o OverflowAttributeOption) applySQSSubscriber(s *SQSSubscriber) {
+	s.overflowKey = string(o)
+}
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const snsNotificationType = "Notification"
+
+// snsNotification is the envelope SNS uses to deliver messages to SQS queues
+// subscribed without raw message delivery.
+type snsNotification struct {
+	Type              string                  `json:"Type"`
+	MessageID         string                  `json:"MessageId"`
+	TopicArn          string                  `json:"TopicArn"`
+	Message           string                  `json:"Message"`
+	MessageAttributes map[string]snsAttribute `json:"MessageAttributes"`
+}
+
+// snsAttribute is a message attribute inside the SNS envelope.
+type snsAttribute struct {
+	Type  string `json:"Type"`
+	Value string `json:"Value"`
+}
+
+// parseSNSNotification returns the SNS envelope if the body is an SNS notification.
+func parseSNSNotification(body string) (*snsNotification, bool) {
+	var n snsNotification
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		return nil, false
+	}
+	if n.Type != snsNotificationType || n.TopicArn == "" {
+		return nil, false
+	}
+
+	return &n, true
+}
+
+// attributes returns the message attributes sent by the publisher as string values,
+// binary values, including custom binary types, are decoded from base64.
+func (n *snsNotification) attributes() map[string]string {
+	att := make(map[string]string, len(n.MessageAttributes))
+	for k, v := range n.MessageAttributes {
+		if AttributeType(v.Type).is(AttributeTypeBinary) {
+			if b, err := base64.StdEncoding.DecodeString(v.Value); err == nil {
+				att[k] = string(b)
+				continue
+			}
+		}
+		att[k] = v.Value
+	}
+
+	return att
+}
@@ -65,32 +65,24 @@ type SNSPublisher struct {
 	fifo bool
 	// metadata key where will be send the message id.
 	msgIDKey string
+	// transforms the message metadata into attributes.
+	attributes attributeEncoder
 }
 
 // Publish sends the provided message to the configured AWS SNS topic.
 // It attaches message metadata as SNS attributes and includes a message ID for tracking.
 // For FIFO topics, it sets ordering and deduplication keys as required.
 func (p SNSPublisher) Publish(ctx context.Context, msg messenger.Message) error {
-	md := msg.Metadata()
-	att := make(map[string]types.MessageAttributeValue)
-
-	for k, v := range md {
-		att[k] = types.MessageAttributeValue{
-			DataType:    awsStringDataType,
-			StringValue: aws.String(v),
-		}
-	}
-
-	att[p.msgIDKey] = types.MessageAttributeValue{
-		DataType:    awsStringDataType,
-		StringValue: aws.String(msg.ID()),
+	att, err := p.attributes.encode(msg, p.msgIDKey)
+	if err != nil {
+		return err
 	}
 
-	_, err := p.cli.Publish(
+	_, err = p.cli.Publish(
 		ctx,
 		&sns.PublishInput{
 			MessageDeduplicationId: p.messageDeduplication(msg),
-			MessageAttributes:      att,
+			MessageAttributes:      snsAttributes(att),
 			Message:                aws.String(string(msg.Payload())),
 			TopicArn:               aws.String(p.topicARN),
 			MessageGroupId:         p.orderingKey(msg),
@@ -129,3 +121,19 @@ func (p SNSPublisher) orderingKey(msg messenger.Message) *string {
 
 	return nil
 }
+
+// snsAttributes transforms the attributes into SNS message attributes.
+func snsAttributes(att map[string]attribute) map[string]types.MessageAttributeValue {
+	res := make(map[string]types.MessageAttributeValue, len(att))
+	for k, a := range att {
+		v := types.MessageAttributeValue{DataType: aws.String(string(a.dataType))}
+		if a.binary() {
+			v.BinaryValue = []byte(a.value)
+		} else {
+			v.StringValue = aws.String(a.value)
+		}
+		res[k] = v
+	}
+
+	return res
+}
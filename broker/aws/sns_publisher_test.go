@@ -3,6 +3,7 @@ package aws_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -136,3 +137,132 @@ func TestSNS_Publish(t *testing.T) {
 		})
 	}
 }
+
+func TestSNS_PublishAttributes(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"amount": "12.5", "signature": "hello", "name": "order"},
+		MsgPayload:  []byte("some message"),
+	}
+
+	t.Run("typed attributes", func(t *testing.T) {
+		t.Parallel()
+
+		snsMock := SNSClientMock{}
+		pub := publisher.NewSNSPublisher(&snsMock, topicARN, publisher.WithAttributeTypes(
+			map[string]publisher.AttributeType{
+				"amount":    publisher.AttributeTypeNumber,
+				"signature": publisher.AttributeTypeBinary,
+			},
+		))
+		require.NoError(t, pub.Publish(context.Background(), m))
+
+		require.Equal(t, map[string]types.MessageAttributeValue{
+			"amount":            {DataType: aws.String("Number"), StringValue: aws.String("12.5")},
+			"signature":         {DataType: aws.String("Binary"), BinaryValue: []byte("hello")},
+			"name":              {DataType: aws.String("String"), StringValue: aws.String("order")},
+			broker.MessageIDKey: {DataType: aws.String("String"), StringValue: aws.String(m.MsgID)},
+		}, snsMock.PublishCalls()[0].Params.MessageAttributes)
+	})
+
+	t.Run("invalid number attribute", func(t *testing.T) {
+		t.Parallel()
+
+		snsMock := SNSClientMock{}
+		pub := publisher.NewSNSPublisher(&snsMock, topicARN, publisher.WithAttributeTypes(
+			map[string]publisher.AttributeType{"name": "Number.int"},
+		))
+
+		require.ErrorIs(t, pub.Publish(context.Background(), m), publisher.ErrInvalidAttribute)
+		require.Empty(t, snsMock.PublishCalls())
+	})
+
+	t.Run("number attribute values", func(t *testing.T) {
+		t.Parallel()
+
+		for value, valid := range map[string]bool{
+			"0":        true,
+			"-12.5":    true,
+			".5":       true,
+			"+1e10":    true,
+			"1.5E-128": true,
+			"1e126":    true,
+			"000.000":  true,
+			"123456789012345678901234567890123456780000": true,
+			"NaN":       false,
+			"Inf":       false,
+			"-Infinity": false,
+			"0x1p3":     false,
+			"1e400":     false,
+			"1.1e126":   false,
+			"1e-129":    false,
+			"1_000":     false,
+			".":         false,
+			"e5":        false,
+			"123456789012345678901234567890123456789": false,
+		} {
+			snsMock := SNSClientMock{}
+			pub := publisher.NewSNSPublisher(&snsMock, topicARN, publisher.WithAttributeTypes(
+				map[string]publisher.AttributeType{"amount": publisher.AttributeTypeNumber},
+			))
+
+			err := pub.Publish(context.Background(), &messenger.GenericMessage{
+				MsgID:       uuid.NewString(),
+				MsgMetadata: map[string]string{"amount": value},
+			})
+			if valid {
+				require.NoError(t, err, value)
+			} else {
+				require.ErrorIs(t, err, publisher.ErrInvalidAttribute, value)
+			}
+		}
+	})
+
+	t.Run("metadata using the overflow attribute key", func(t *testing.T) {
+		t.Parallel()
+
+		snsMock := SNSClientMock{}
+		pub := publisher.NewSNSPublisher(&snsMock, topicARN, publisher.WithOverflowAttribute("name"))
+
+		require.ErrorIs(t, pub.Publish(context.Background(), m), publisher.ErrInvalidAttribute)
+		require.Empty(t, snsMock.PublishCalls())
+	})
+
+	t.Run("too many typed attributes to overflow", func(t *testing.T) {
+		t.Parallel()
+
+		md := make(map[string]string, 12)
+		attTypes := make(map[string]publisher.AttributeType, 12)
+		for i := range 12 {
+			md[fmt.Sprintf("key_%02d", i)] = fmt.Sprint(i)
+			attTypes[fmt.Sprintf("key_%02d", i)] = publisher.AttributeTypeNumber
+		}
+
+		snsMock := SNSClientMock{}
+		pub := publisher.NewSNSPublisher(&snsMock, topicARN,
+			publisher.WithAttributeTypes(attTypes),
+			publisher.WithOverflowAttribute("overflow"),
+		)
+
+		err := pub.Publish(context.Background(), &messenger.GenericMessage{MsgID: uuid.NewString(), MsgMetadata: md})
+		require.ErrorIs(t, err, publisher.ErrTooManyAttributes)
+		require.Empty(t, snsMock.PublishCalls())
+	})
+
+	t.Run("too many attributes", func(t *testing.T) {
+		t.Parallel()
+
+		m := &messenger.GenericMessage{MsgID: uuid.NewString(), MsgMetadata: map[string]string{}}
+		for i := range 10 {
+			m.MsgMetadata[fmt.Sprintf("key_%d", i)] = "value"
+		}
+
+		snsMock := SNSClientMock{}
+		pub := publisher.NewSNSPublisher(&snsMock, topicARN)
+
+		require.ErrorIs(t, pub.Publish(context.Background(), m), publisher.ErrTooManyAttributes)
+		require.Empty(t, snsMock.PublishCalls())
+	})
+}
@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/x4b1/messenger"
+)
+
+// maxDeleteBatch is the max number of entries allowed by SQS in a DeleteMessageBatch request.
+const maxDeleteBatch = 10
+
+// newSQSBatchDeleter returns a deleter that groups the messages to delete from the queue
+// in batches, flushing them when the batch is full or the window since the first pending
+// message elapses.
+func newSQSBatchDeleter(
+	cli SQSClient,
+	queueURL *string,
+	window time.Duration,
+	errHandler messenger.ErrorHandler,
+) *sqsBatchDeleter {
+	return &sqsBatchDeleter{
+		cli:        cli,
+		queueURL:   queueURL,
+		window:     window,
+		errHandler: errHandler,
+		handles:    make(chan *string, maxDeleteBatch),
+		done:       make(chan struct{}),
+	}
+}
+
+// sqsBatchDeleter acknowledges messages with DeleteMessageBatch.
+type sqsBatchDeleter struct {
+	cli        SQSClient
+	queueURL   *string
+	window     time.Duration
+	errHandler messenger.ErrorHandler
+
+	// receipt handles of the messages pending to delete.
+	handles chan *string
+	done    chan struct{}
+}
+
+// run collects the receipt handles until the deleter is closed.
+// It is not cancelled with the context, messages already processed must be deleted
+// even if the subscriber is stopping.
+func (d *sqsBatchDeleter) run(ctx context.Context) {
+	defer close(d.done)
+
+	ctx = context.WithoutCancel(ctx)
+
+	batch := make([]*string, 0, maxDeleteBatch)
+	flush := func() {
+		if len(batch) > 0 {
+			d.flush(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+
+	t := time.NewTimer(d.window)
+	t.Stop()
+	defer t.Stop()
+
+	for {
+		select {
+		case h, ok := <-d.handles:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, h)
+			if len(batch) == 1 {
+				t.Reset(d.window)
+			}
+			if len(batch) == maxDeleteBatch {
+				t.Stop()
+				flush()
+			}
+		case <-t.C:
+			flush()
+		}
+	}
+}
+
+// delete adds the message to the pending batch.
+func (d *sqsBatchDeleter) delete(msg types.Message) {
+	d.handles <- msg.ReceiptHandle
+}
+
+// close flushes the pending messages and waits until they are deleted.
+func (d *sqsBatchDeleter) close() {
+	close(d.handles)
+	<-d.done
+}
+
+// flush deletes the batch of messages, reporting the entries that failed.
+func (d *sqsBatchDeleter) flush(ctx context.Context, handles []*string) {
+	entries := make([]types.DeleteMessageBatchRequestEntry, len(handles))
+	for i, h := range handles {
+		entries[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: h,
+		}
+	}
+
+	out, err := d.cli.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: d.queueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		d.errHandler.Error(ctx, fmt.Errorf("deleting messages batch: %w", err))
+		return
+	}
+
+	for _, f := range out.Failed {
+		d.errHandler.Error(ctx, fmt.Errorf(
+			"deleting message %s from batch: %s: %s",
+			aws.ToString(f.Id),
+			aws.ToString(f.Code),
+			aws.ToString(f.Message),
+		))
+	}
+}
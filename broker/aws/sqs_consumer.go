@@ -0,0 +1,380 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/x4b1/messenger"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// maxVisibilityTimeout is the max visibility timeout allowed by SQS.
+	maxVisibilityTimeout = 12 * time.Hour
+	// maxFailureReasonSize is the max length of the failure reason sent to the dead letter queue.
+	maxFailureReasonSize = 1024
+)
+
+// newSQSConsumer returns the consumer of one subscription queue, bounding the messages
+// being processed at the same time to the subscriber max in flight setup.
+func newSQSConsumer(s *SQSSubscriber, sub messenger.Subscription, queueURL, deadLetterURL *string) *sqsConsumer {
+	concurrency := max(s.concurrency, 1)
+	pollers := max(s.pollers, 1)
+	maxInFlight := s.maxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = max(concurrency, pollers) * max(s.maxMessages, 1)
+	}
+
+	c := sqsConsumer{
+		s:             s,
+		sub:           sub,
+		queueURL:      queueURL,
+		deadLetterURL: deadLetterURL,
+		concurrency:   concurrency,
+		pollers:       pollers,
+		inFlight:      semaphore.NewWeighted(int64(maxInFlight)),
+		msgs:          make(chan types.Message, maxInFlight),
+	}
+	if s.deleteBatchWindow > 0 {
+		c.deleter = newSQSBatchDeleter(s.cli, queueURL, s.deleteBatchWindow, s.errHandler)
+	}
+
+	return &c
+}
+
+// sqsConsumer polls messages from one queue and dispatches them to a pool of workers
+// that handle them with the subscription.
+type sqsConsumer struct {
+	s        *SQSSubscriber
+	sub      messenger.Subscription
+	queueURL *string
+	// dead letter queue url, nil if disabled.
+	deadLetterURL *string
+
+	concurrency int
+	pollers     int
+	// holds a slot for every received message until it is processed.
+	inFlight *semaphore.Weighted
+	msgs     chan types.Message
+	// deletes the processed messages in batches, nil if disabled.
+	deleter *sqsBatchDeleter
+}
+
+// run starts the workers and keeps receiving messages until the context is done.
+// Once it stops receiving it waits the workers to process the already received messages,
+// the messages are handled with handleCtx so they are not cancelled when receiving stops.
+func (c *sqsConsumer) run(ctx, handleCtx context.Context) error {
+	if c.deleter != nil {
+		go c.deleter.run(handleCtx)
+		defer c.deleter.close()
+	}
+
+	var wg sync.WaitGroup
+	for range c.concurrency {
+		wg.Go(func() {
+			for msg := range c.msgs {
+				c.handle(handleCtx, msg)
+				c.inFlight.Release(1)
+			}
+		})
+	}
+
+	pollers, ctx := errgroup.WithContext(ctx)
+	for range c.pollers {
+		pollers.Go(func() error {
+			return c.poll(ctx)
+		})
+	}
+
+	err := pollers.Wait()
+	close(c.msgs)
+	wg.Wait()
+
+	return err
+}
+
+// poll receives messages while there are free in flight slots, so receiving continues
+// while the workers are busy.
+func (c *sqsConsumer) poll(ctx context.Context) error {
+	for {
+		n, err := c.acquire(ctx)
+		if err != nil {
+			// the subscriber is stopping.
+			return nil
+		}
+
+		out, err := c.s.cli.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    c.queueURL,
+			MaxNumberOfMessages:         int32(n),
+			WaitTimeSeconds:             int32(c.s.maxWaitSeconds),
+			VisibilityTimeout:           int32(c.s.visibilityTimeout.Seconds()),
+			MessageAttributeNames:       []string{"All"},
+			MessageSystemAttributeNames: slices.Sorted(maps.Keys(sqsSystemAttributes)),
+		})
+		if err != nil {
+			c.inFlight.Release(int64(n))
+			if ctx.Err() != nil {
+				// the subscriber is stopping, the receive was aborted.
+				return nil
+			}
+
+			return fmt.Errorf("%s: %w", c.sub.Name(), err)
+		}
+
+		c.inFlight.Release(int64(n - len(out.Messages)))
+		for _, msg := range out.Messages {
+			c.msgs <- msg
+		}
+	}
+}
+
+// acquire blocks until there is at least one free in flight slot and returns how many
+// messages can be received, up to the subscriber max messages.
+func (c *sqsConsumer) acquire(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := c.inFlight.Acquire(ctx, 1); err != nil {
+		return 0, err
+	}
+
+	n := 1
+	for n < c.s.maxMessages && c.inFlight.TryAcquire(1) {
+		n++
+	}
+
+	return n, nil
+}
+
+// handle processes the message with the subscription and deletes it from the queue on success.
+func (c *sqsConsumer) handle(ctx context.Context, msg types.Message) {
+	// the heartbeat stops before deleting or delaying the message, so it does not override the backoff.
+	stop := c.heartbeat(ctx, msg)
+	err := c.s.processMessage(ctx, c.sub, msg)
+	stop()
+
+	if err != nil {
+		c.s.errHandler.Error(ctx, err)
+		c.nack(ctx, msg, err)
+
+		return
+	}
+
+	c.delete(ctx, msg)
+}
+
+// delete removes the message from the queue. It is not cancelled with the context,
+// a message already processed must be deleted even if the subscriber is stopping.
+func (c *sqsConsumer) delete(ctx context.Context, msg types.Message) {
+	if c.deleter != nil {
+		c.deleter.delete(msg)
+		return
+	}
+
+	if _, err := c.s.cli.DeleteMessage(context.WithoutCancel(ctx), &sqs.DeleteMessageInput{
+		ReceiptHandle: msg.ReceiptHandle,
+		QueueUrl:      c.queueURL,
+	}); err != nil {
+		c.s.errHandler.Error(ctx, err)
+	}
+}
+
+// nack applies the retry policy to a failed message. It forwards the message to the dead letter queue
+// if it has been received too many times or failed permanently, otherwise it delays its redelivery
+// with the backoff.
+func (c *sqsConsumer) nack(ctx context.Context, msg types.Message, cause error) {
+	receives, _ := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+
+	if c.deadLetterURL != nil && (receives >= c.s.deadLetter.maxReceives || messenger.IsPermanent(cause)) {
+		if err := c.deadLetter(ctx, msg, cause); err != nil {
+			c.s.errHandler.Error(ctx, err)
+			return
+		}
+		c.delete(ctx, msg)
+
+		return
+	}
+
+	if c.s.backoff.initial <= 0 {
+		return
+	}
+
+	if _, err := c.s.cli.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          c.queueURL,
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: int32(c.backoff(receives).Seconds()),
+	}); err != nil {
+		c.s.errHandler.Error(ctx, fmt.Errorf("delaying failed message: %w", err))
+	}
+}
+
+// backoff returns the exponential delay for a message received the given times,
+// bounded by the max backoff and the max visibility timeout allowed by SQS.
+func (c *sqsConsumer) backoff(receives int) time.Duration {
+	maxBackoff := maxVisibilityTimeout
+	if c.s.backoff.max > 0 {
+		maxBackoff = min(c.s.backoff.max, maxVisibilityTimeout)
+	}
+
+	d := c.s.backoff.initial
+	for i := 1; i < receives && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, maxBackoff)
+}
+
+// deadLetter sends the message with the failure reason to the dead letter queue.
+func (c *sqsConsumer) deadLetter(ctx context.Context, msg types.Message, cause error) error {
+	att := c.deadLetterAttributes(ctx, msg, truncate(cause.Error(), maxFailureReasonSize))
+
+	in := &sqs.SendMessageInput{
+		QueueUrl:          c.deadLetterURL,
+		MessageBody:       msg.Body,
+		MessageAttributes: att,
+	}
+	if groupID, ok := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; ok {
+		in.MessageGroupId = aws.String(groupID)
+		in.MessageDeduplicationId = msg.MessageId
+	}
+
+	if _, err := c.s.cli.SendMessage(ctx, in); err != nil {
+		return fmt.Errorf("forwarding message to dead letter queue: %w", err)
+	}
+
+	return nil
+}
+
+// deadLetterAttributes returns the message attributes adding the failure reason. If the message already has
+// the max number of attributes, the reason is packed in the overflow attribute when it is setup, moving there
+// the last attribute by key if the message does not have it yet. Without overflow attribute the last attribute
+// by key is dropped. The message id attribute is always kept.
+func (c *sqsConsumer) deadLetterAttributes(
+	ctx context.Context,
+	msg types.Message,
+	reason string,
+) map[string]types.MessageAttributeValue {
+	att := maps.Clone(msg.MessageAttributes)
+	if att == nil {
+		att = make(map[string]types.MessageAttributeValue, 1)
+	}
+	delete(att, FailureReasonKey)
+
+	if len(att) < maxAttributes {
+		att[FailureReasonKey] = types.MessageAttributeValue{DataType: awsStringDataType, StringValue: aws.String(reason)}
+
+		return att
+	}
+
+	if c.s.overflowKey == "" {
+		key := c.lastAttribute(att)
+		delete(att, key)
+		c.s.errHandler.Error(ctx, fmt.Errorf(
+			"dropping attribute %s of message %s forwarded to the dead letter queue, it exceeds the attributes limit",
+			key,
+			aws.ToString(msg.MessageId),
+		))
+		att[FailureReasonKey] = types.MessageAttributeValue{DataType: awsStringDataType, StringValue: aws.String(reason)}
+
+		return att
+	}
+
+	packed := make(map[string]string, 2)
+	if overflow, ok := att[c.s.overflowKey]; ok {
+		if err := json.Unmarshal([]byte(attributeValue(overflow)), &packed); err != nil {
+			// not packed by the publisher, it is packed under its own key.
+			packed = map[string]string{c.s.overflowKey: attributeValue(overflow)}
+		}
+	} else {
+		// the last attribute by key makes room for the overflow attribute.
+		key := c.lastAttribute(att)
+		packed[key] = attributeValue(att[key])
+		delete(att, key)
+	}
+
+	packed[FailureReasonKey] = reason
+	value, _ := json.Marshal(packed)
+	att[c.s.overflowKey] = types.MessageAttributeValue{DataType: awsStringDataType, StringValue: aws.String(string(value))}
+
+	return att
+}
+
+// lastAttribute returns the last attribute key in order, other than the message id.
+func (c *sqsConsumer) lastAttribute(att map[string]types.MessageAttributeValue) string {
+	keys := slices.Sorted(maps.Keys(att))
+	if key := keys[len(keys)-1]; key != c.s.msgIDKey {
+		return key
+	}
+
+	return keys[len(keys)-2]
+}
+
+// attributeValue returns the string or binary value of the attribute.
+func attributeValue(v types.MessageAttributeValue) string {
+	if v.BinaryValue != nil {
+		return string(v.BinaryValue)
+	}
+
+	return aws.ToString(v.StringValue)
+}
+
+// truncate returns the first n bytes of s, without splitting a multi-byte character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+// heartbeat extends periodically the message visibility timeout while it is being processed,
+// it returns a function to stop it.
+func (c *sqsConsumer) heartbeat(ctx context.Context, msg types.Message) func() {
+	if c.s.heartbeat.interval <= 0 {
+		return func() {}
+	}
+
+	// keeps extending while the handler runs, even if the subscriber is stopping.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		t := time.NewTicker(c.s.heartbeat.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_, err := c.s.cli.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          c.queueURL,
+					ReceiptHandle:     msg.ReceiptHandle,
+					VisibilityTimeout: int32(c.s.heartbeat.visibilityTimeout.Seconds()),
+				})
+				if err != nil && ctx.Err() == nil {
+					c.s.errHandler.Error(ctx, fmt.Errorf("extending message visibility: %w", err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
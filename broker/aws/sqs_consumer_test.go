@@ -0,0 +1,396 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	awsx "github.com/x4b1/messenger/broker/aws"
+)
+
+const dlqURL = "https://sqs.eu-west-1.amazonaws.com/12345/test-dlq"
+
+func failedMessage(receives string) *sqs.ReceiveMessageOutput {
+	return &sqs.ReceiveMessageOutput{
+		Messages: []types.Message{
+			{
+				MessageId:     aws.String(awsMessageID),
+				ReceiptHandle: aws.String("receipt-handle"),
+				Body:          aws.String("hello world"),
+				Attributes: map[string]string{
+					string(types.MessageSystemAttributeNameApproximateReceiveCount): receives,
+				},
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"AN": {DataType: aws.String("String"), StringValue: aws.String("ATTRIBUTE")},
+				},
+			},
+		},
+	}
+}
+
+func listenOnce(
+	t *testing.T,
+	out *sqs.ReceiveMessageOutput,
+	h messenger.SubscriptionHandler,
+	cli *SQSClientMock,
+	opts ...awsx.SQSSubscriberOption,
+) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if cli.GetQueueUrlFunc == nil {
+		cli.GetQueueUrlFunc = func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+			return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/12345/test")}, nil
+		}
+	}
+	cli.ReceiveMessageFunc = func(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+		cancel()
+
+		return out, nil
+	}
+	if cli.DeleteMessageBatchFunc == nil {
+		cli.DeleteMessageBatchFunc = func(context.Context, *sqs.DeleteMessageBatchInput, ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+			return &sqs.DeleteMessageBatchOutput{}, nil
+		}
+	}
+
+	s := awsx.NewSQSSubscriber(cli, opts...)
+	s.Register(messenger.NewSubscription("test", h))
+
+	require.NoError(t, s.Listen(ctx))
+}
+
+func TestSQSSubscriber_RetryPolicy(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	failing := func(context.Context, messenger.Message) error { return handlerErr }
+
+	t.Run("without policy leaves message in queue", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("1"), failing, cli)
+
+		require.Empty(t, cli.ChangeMessageVisibilityCalls())
+		require.Empty(t, cli.DeleteMessageCalls())
+		require.Empty(t, cli.SendMessageCalls())
+	})
+
+	for _, tc := range []struct {
+		name       string
+		receives   string
+		backoff    awsx.BackoffOption
+		visibility int32
+	}{
+		{"first receive", "1", awsx.WithBackoff(10*time.Second, time.Hour), 10},
+		{"exponential", "3", awsx.WithBackoff(10*time.Second, time.Hour), 40},
+		{"bounded by max", "10", awsx.WithBackoff(10*time.Second, time.Minute), 60},
+		{"bounded by sqs max", "30", awsx.WithBackoff(time.Minute, 0), 43200},
+	} {
+		t.Run("backoff "+tc.name, func(t *testing.T) {
+			cli := &SQSClientMock{}
+			listenOnce(t, failedMessage(tc.receives), failing, cli, tc.backoff)
+
+			calls := cli.ChangeMessageVisibilityCalls()
+			require.Len(t, calls, 1)
+			require.Equal(t, "receipt-handle", aws.ToString(calls[0].ChangeMessageVisibilityInput.ReceiptHandle))
+			require.Equal(t, tc.visibility, calls[0].ChangeMessageVisibilityInput.VisibilityTimeout)
+			require.Empty(t, cli.DeleteMessageCalls())
+		})
+	}
+
+	t.Run("backoff is not overridden by the visibility heartbeat", func(t *testing.T) {
+		cli := &SQSClientMock{
+			ChangeMessageVisibilityFunc: func(_ context.Context, in *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+				if in.VisibilityTimeout == 10 {
+					// gives time to a heartbeat still running to extend the visibility.
+					time.Sleep(30 * time.Millisecond)
+				}
+
+				return &sqs.ChangeMessageVisibilityOutput{}, nil
+			},
+		}
+		listenOnce(t, failedMessage("1"), func(context.Context, messenger.Message) error {
+			time.Sleep(35 * time.Millisecond)
+			return handlerErr
+		}, cli, awsx.WithBackoff(10*time.Second, time.Hour), awsx.WithVisibilityHeartbeat(10*time.Millisecond, time.Minute))
+
+		calls := cli.ChangeMessageVisibilityCalls()
+		require.Greater(t, len(calls), 1)
+		require.Equal(t, int32(60), calls[0].ChangeMessageVisibilityInput.VisibilityTimeout)
+		require.Equal(t, int32(10), calls[len(calls)-1].ChangeMessageVisibilityInput.VisibilityTimeout)
+	})
+
+	t.Run("forwards to dead letter queue after max receives", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("3"), failing, cli,
+			awsx.WithDeadLetterQueue(dlqURL, 3),
+			awsx.WithBackoff(time.Second, 0),
+		)
+
+		sends := cli.SendMessageCalls()
+		require.Len(t, sends, 1)
+		require.Equal(t, dlqURL, aws.ToString(sends[0].SendMessageInput.QueueUrl))
+		require.Equal(t, "hello world", aws.ToString(sends[0].SendMessageInput.MessageBody))
+		require.Equal(t, "ATTRIBUTE", aws.ToString(sends[0].SendMessageInput.MessageAttributes["AN"].StringValue))
+		require.Equal(
+			t,
+			handlerErr.Error(),
+			aws.ToString(sends[0].SendMessageInput.MessageAttributes[awsx.FailureReasonKey].StringValue),
+		)
+
+		require.Len(t, cli.DeleteMessageCalls(), 1)
+		require.Empty(t, cli.ChangeMessageVisibilityCalls())
+	})
+
+	t.Run("forwards on first failure without max receives", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("1"), failing, cli,
+			awsx.WithDeadLetterQueue(dlqURL, 0),
+			awsx.WithBackoff(time.Second, 0),
+		)
+
+		require.Len(t, cli.SendMessageCalls(), 1)
+		require.Len(t, cli.DeleteMessageCalls(), 1)
+		require.Empty(t, cli.ChangeMessageVisibilityCalls())
+	})
+
+	t.Run("truncates the failure reason", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("3"), func(context.Context, messenger.Message) error {
+			return errors.New(strings.Repeat("ñ", 1000))
+		}, cli, awsx.WithDeadLetterQueue(dlqURL, 3))
+
+		sends := cli.SendMessageCalls()
+		require.Len(t, sends, 1)
+		reason := aws.ToString(sends[0].SendMessageInput.MessageAttributes[awsx.FailureReasonKey].StringValue)
+		require.Equal(t, strings.Repeat("ñ", 512), reason)
+	})
+
+	t.Run("forwards permanent errors to dead letter queue", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("1"), func(context.Context, messenger.Message) error {
+			return messenger.Permanent(handlerErr)
+		}, cli, awsx.WithDeadLetterQueue(dlqURL, 5))
+
+		require.Len(t, cli.SendMessageCalls(), 1)
+		require.Len(t, cli.DeleteMessageCalls(), 1)
+	})
+
+	t.Run("below max receives backs off", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, failedMessage("2"), failing, cli,
+			awsx.WithDeadLetterQueue(dlqURL, 3),
+			awsx.WithBackoff(time.Second, 0),
+		)
+
+		require.Empty(t, cli.SendMessageCalls())
+		require.Len(t, cli.ChangeMessageVisibilityCalls(), 1)
+	})
+
+	t.Run("keeps the attributes limit", func(t *testing.T) {
+		fullMessage := func(overflow bool) *sqs.ReceiveMessageOutput {
+			out := failedMessage("3")
+			att := out.Messages[0].MessageAttributes
+			for i := range 9 {
+				att[fmt.Sprintf("key%d", i)] = types.MessageAttributeValue{
+					DataType:    aws.String("String"),
+					StringValue: aws.String(fmt.Sprint(i)),
+				}
+			}
+			delete(att, "AN")
+			if overflow {
+				att["key8"] = types.MessageAttributeValue{
+					DataType:    aws.String("String"),
+					StringValue: aws.String(`{"key9":"9"}`),
+				}
+			}
+			att[broker.MessageIDKey] = types.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(customMsgID),
+			}
+
+			return out
+		}
+
+		for _, tc := range []struct {
+			name        string
+			overflow    bool
+			overflowKey string
+			removed     string
+			packed      string
+		}{
+			{name: "drops last attribute", removed: "key8"},
+			{
+				name:        "packs last attribute in overflow",
+				overflowKey: "overflow",
+				removed:     "key8",
+				packed:      `{"failure_reason":"handler error","key8":"8"}`,
+			},
+			{
+				name:        "adds to overflow attribute",
+				overflow:    true,
+				overflowKey: "key8",
+				packed:      `{"failure_reason":"handler error","key9":"9"}`,
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				cli := &SQSClientMock{}
+				out := fullMessage(tc.overflow)
+				listenOnce(t, out, failing, cli,
+					awsx.WithDeadLetterQueue(dlqURL, 3),
+					awsx.WithOverflowAttribute(tc.overflowKey),
+				)
+
+				sends := cli.SendMessageCalls()
+				require.Len(t, sends, 1)
+				att := sends[0].SendMessageInput.MessageAttributes
+				require.Len(t, att, 10)
+				require.Equal(t, customMsgID, aws.ToString(att[broker.MessageIDKey].StringValue))
+				if tc.removed != "" {
+					require.NotContains(t, att, tc.removed)
+				}
+				if tc.packed != "" {
+					require.JSONEq(t, tc.packed, aws.ToString(att[tc.overflowKey].StringValue))
+				}
+			})
+		}
+	})
+
+	t.Run("forwarding fails keeps message", func(t *testing.T) {
+		cli := &SQSClientMock{
+			SendMessageFunc: func(context.Context, *sqs.SendMessageInput, ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				return nil, errUnexpected
+			},
+		}
+		listenOnce(t, failedMessage("3"), failing, cli, awsx.WithDeadLetterQueue(dlqURL, 3))
+
+		require.Len(t, cli.SendMessageCalls(), 1)
+		require.Empty(t, cli.DeleteMessageCalls())
+	})
+}
+
+func TestSQSSubscriber_Receive(t *testing.T) {
+	success := func(context.Context, messenger.Message) error { return nil }
+
+	messages := func(n int) *sqs.ReceiveMessageOutput {
+		out := &sqs.ReceiveMessageOutput{}
+		for i := range n {
+			out.Messages = append(out.Messages, types.Message{
+				MessageId:     aws.String(awsMessageID),
+				ReceiptHandle: aws.String(fmt.Sprintf("receipt-%d", i)),
+				Body:          aws.String("hello world"),
+			})
+		}
+
+		return out
+	}
+
+	t.Run("deletes in batches", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, messages(12), success, cli,
+			awsx.WithMaxMessages(12),
+			awsx.WithDeleteBatch(time.Hour),
+		)
+
+		require.Empty(t, cli.DeleteMessageCalls())
+
+		calls := cli.DeleteMessageBatchCalls()
+		require.Len(t, calls, 2)
+		require.Len(t, calls[0].DeleteMessageBatchInput.Entries, 10)
+		require.Len(t, calls[1].DeleteMessageBatchInput.Entries, 2)
+
+		handles := []string{}
+		for _, c := range calls {
+			require.Equal(t, "https://sqs.eu-west-1.amazonaws.com/12345/test", aws.ToString(c.DeleteMessageBatchInput.QueueUrl))
+			for _, e := range c.DeleteMessageBatchInput.Entries {
+				handles = append(handles, aws.ToString(e.ReceiptHandle))
+			}
+		}
+		require.Len(t, handles, 12)
+		require.Contains(t, handles, "receipt-11")
+	})
+
+	t.Run("flushes batch after window", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var once sync.Once
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/12345/test")}, nil
+			},
+			DeleteMessageBatchFunc: func(context.Context, *sqs.DeleteMessageBatchInput, ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+				cancel()
+				return &sqs.DeleteMessageBatchOutput{}, nil
+			},
+		}
+		cli.ReceiveMessageFunc = func(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			first := false
+			once.Do(func() { first = true })
+			if first {
+				return messages(2), nil
+			}
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		}
+
+		s := awsx.NewSQSSubscriber(cli, awsx.WithMaxMessages(2), awsx.WithDeleteBatch(10*time.Millisecond))
+		s.Register(messenger.NewSubscription("test", success))
+
+		require.NoError(t, s.Listen(ctx))
+
+		calls := cli.DeleteMessageBatchCalls()
+		require.Len(t, calls, 1)
+		require.Len(t, calls[0].DeleteMessageBatchInput.Entries, 2)
+	})
+
+	t.Run("sets receive visibility timeout", func(t *testing.T) {
+		cli := &SQSClientMock{}
+		listenOnce(t, messages(1), success, cli, awsx.WithVisibilityTimeout(2*time.Minute))
+
+		require.Equal(t, int32(120), cli.ReceiveMessageCalls()[0].ReceiveMessageInput.VisibilityTimeout)
+	})
+
+	t.Run("polls in parallel", func(t *testing.T) {
+		const pollers = 3
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var (
+			mu      sync.Mutex
+			polling int
+		)
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/12345/test")}, nil
+			},
+			ReceiveMessageFunc: func(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+				mu.Lock()
+				polling++
+				if polling == pollers {
+					cancel()
+				}
+				mu.Unlock()
+
+				<-ctx.Done()
+
+				return nil, ctx.Err()
+			},
+		}
+
+		s := awsx.NewSQSSubscriber(cli, awsx.WithPollers(pollers))
+		s.Register(messenger.NewSubscription("test", success))
+
+		require.NoError(t, s.Listen(ctx))
+		require.Len(t, cli.ReceiveMessageCalls(), pollers)
+	})
+}
@@ -22,7 +22,7 @@ type SQSPublisherOption interface {
 // OpenSQSPublisher creates a new SQSPublisher using the default AWS configuration.
 func OpenSQSPublisher(
 	ctx context.Context,
-	queueARN string,
+	queue string,
 	opts ...SQSPublisherOption,
 ) (*SQSPublisher, error) {
 	cfg, err := config.LoadDefaultConfig(ctx)
@@ -30,20 +30,22 @@ func OpenSQSPublisher(
 		return nil, err
 	}
 
-	return NewSQSPublisher(sqs.NewFromConfig(cfg), queueARN, opts...), nil
+	return NewSQSPublisher(sqs.NewFromConfig(cfg), queue, opts...), nil
 }
 
-// NewSQSPublisher creates a new SQSPublisher with the given SQS client and queue ARN.
-func NewSQSPublisher(svc SQSClient, queueARN string, opts ...SQSPublisherOption) *SQSPublisher {
+// NewSQSPublisher creates a new SQSPublisher with the given SQS client and queue name, ARN or url.
+// The queue url is resolved on the first publish.
+func NewSQSPublisher(svc SQSClient, queue string, opts ...SQSPublisherOption) *SQSPublisher {
 	p := SQSPublisher{
 		svc:      svc,
-		queue:    queueARN,
+		queue:    queue,
 		msgIDKey: broker.MessageIDKey,
 	}
 
 	for _, opt := range opts {
 		opt.applySQSPublisher(&p)
 	}
+	p.queues = newSQSQueueResolver(svc, p.queueOwner)
 
 	return &p
 }
@@ -55,8 +57,12 @@ func NewSQSPublisher(svc SQSClient, queueARN string, opts ...SQSPublisherOption)
 type SQSPublisher struct {
 	// sqs service instance where are going to publish messages
 	svc SQSClient
-	// queue url where are going to publish messages
+	// queue name, arn or url where are going to publish messages
 	queue string
+	// account that owns the queue when given by name
+	queueOwner string
+	// resolves the queue url
+	queues *sqsQueueResolver
 	// meta property of the message to use as ordering key
 	metaOrdKey string
 	// default ordering key in case not provided in message metadata
@@ -65,32 +71,29 @@ type SQSPublisher struct {
 	fifo bool
 	// metadata key where will be send the message id.
 	msgIDKey string
+	// transforms the message metadata into attributes.
+	attributes attributeEncoder
 }
 
-// Publish publishes the given message to the pubsub topic.
+// Publish publishes the given message to the SQS queue.
 func (p SQSPublisher) Publish(ctx context.Context, msg messenger.Message) error {
-	md := msg.Metadata()
-	att := make(map[string]types.MessageAttributeValue)
-
-	for k, v := range md {
-		att[k] = types.MessageAttributeValue{
-			DataType:    awsStringDataType,
-			StringValue: aws.String(v),
-		}
+	att, err := p.attributes.encode(msg, p.msgIDKey)
+	if err != nil {
+		return err
 	}
 
-	att[p.msgIDKey] = types.MessageAttributeValue{
-		DataType:    awsStringDataType,
-		StringValue: aws.String(msg.ID()),
+	queueURL, err := p.queues.resolve(ctx, p.queue)
+	if err != nil {
+		return err
 	}
 
-	_, err := p.svc.SendMessage(
+	_, err = p.svc.SendMessage(
 		ctx,
 		&sqs.SendMessageInput{
 			MessageDeduplicationId: p.messageDeduplication(msg),
-			MessageAttributes:      att,
+			MessageAttributes:      sqsAttributes(att),
 			MessageBody:            aws.String(string(msg.Payload())),
-			QueueUrl:               aws.String(p.queue),
+			QueueUrl:               queueURL,
 			MessageGroupId:         p.orderingKey(msg),
 		})
 	if err != nil {
@@ -127,3 +130,19 @@ func (p SQSPublisher) orderingKey(msg messenger.Message) *string {
 
 	return nil
 }
+
+// sqsAttributes transforms the attributes into SQS message attributes.
+func sqsAttributes(att map[string]attribute) map[string]types.MessageAttributeValue {
+	res := make(map[string]types.MessageAttributeValue, len(att))
+	for k, a := range att {
+		v := types.MessageAttributeValue{DataType: aws.String(string(a.dataType))}
+		if a.binary() {
+			v.BinaryValue = []byte(a.value)
+		} else {
+			v.StringValue = aws.String(a.value)
+		}
+		res[k] = v
+	}
+
+	return res
+}
@@ -2,12 +2,15 @@ package aws_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
 	"github.com/x4b1/messenger/broker"
 	publisher "github.com/x4b1/messenger/broker/aws"
 )
@@ -121,3 +124,46 @@ func TestPublish(t *testing.T) {
 		})
 	}
 }
+
+func TestPublish_OverflowAttribute(t *testing.T) {
+	t.Parallel()
+
+	md := map[string]string{"amount": "10"}
+	for i := range 12 {
+		md[fmt.Sprintf("key_%02d", i)] = fmt.Sprint(i)
+	}
+	m := &messenger.GenericMessage{MsgID: uuid.NewString(), MsgMetadata: md, MsgPayload: []byte("hello world")}
+
+	sqsMock := SQSClientMock{}
+	pub := publisher.NewSQSPublisher(
+		&sqsMock,
+		queueARN,
+		publisher.WithAttributeTypes(map[string]publisher.AttributeType{"amount": publisher.AttributeTypeNumber}),
+		publisher.WithOverflowAttribute("overflow"),
+	)
+	require.NoError(t, pub.Publish(context.Background(), m))
+
+	att := sqsMock.SendMessageCalls()[0].SendMessageInput.MessageAttributes
+	require.Len(t, att, 10)
+	require.Equal(t, types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(m.MsgID)}, att[broker.MessageIDKey])
+	require.Equal(t, types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String("10")}, att["amount"])
+	for i := range 7 {
+		require.Contains(t, att, fmt.Sprintf("key_%02d", i))
+	}
+	require.JSONEq(t,
+		`{"key_07":"7","key_08":"8","key_09":"9","key_10":"10","key_11":"11"}`,
+		aws.ToString(att["overflow"].StringValue),
+	)
+
+	// the subscriber unpacks the overflow attribute.
+	var got messenger.Message
+	listenOnce(t, &sqs.ReceiveMessageOutput{
+		Messages: []types.Message{{MessageId: aws.String(awsMessageID), Body: aws.String("hello world"), MessageAttributes: att}},
+	}, func(_ context.Context, msg messenger.Message) error {
+		got = msg
+		return nil
+	}, &SQSClientMock{}, publisher.WithOverflowAttribute("overflow"))
+
+	require.Equal(t, m.MsgID, got.ID())
+	require.Equal(t, messenger.Metadata(md), got.Metadata())
+}
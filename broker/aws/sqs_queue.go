@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"golang.org/x/sync/singleflight"
+)
+
+// Errors returned when a queue given as an ARN cannot be used.
+var (
+	ErrInvalidQueueARN     = errors.New("invalid sqs queue arn")
+	ErrQueueRegionMismatch = errors.New("sqs queue arn region does not match the client region")
+)
+
+// newSQSQueueResolver returns a resolver of queue urls that looks up queue names
+// owned by the given account, or the caller account if empty.
+func newSQSQueueResolver(cli SQSClient, owner string) *sqsQueueResolver {
+	return &sqsQueueResolver{
+		cli:   cli,
+		owner: owner,
+		urls:  make(map[string]*string),
+	}
+}
+
+// sqsQueueResolver resolves queue urls from queue names, ARNs or urls,
+// caching the resolved urls so every queue is only looked up once.
+type sqsQueueResolver struct {
+	cli   SQSClient
+	owner string
+
+	// concurrent lookups of the same queue share the request, without waiting for the other queues.
+	lookups singleflight.Group
+	mu      sync.Mutex
+	urls    map[string]*string
+}
+
+// resolve returns the url of the given queue. Urls are returned as they are, ARNs are looked up
+// by the queue name and the account in the ARN, and names by the name and the resolver owner account.
+// ARNs of a region different than the client one are rejected with ErrQueueRegionMismatch,
+// as the requests to the queue are sent to the client region.
+func (r *sqsQueueResolver) resolve(ctx context.Context, queue string) (*string, error) {
+	if strings.HasPrefix(queue, "https://") || strings.HasPrefix(queue, "http://") {
+		return aws.String(queue), nil
+	}
+
+	r.mu.Lock()
+	url, ok := r.urls[queue]
+	r.mu.Unlock()
+	if ok {
+		return url, nil
+	}
+
+	in, err := r.input(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err, _ := r.lookups.Do(queue, func() (any, error) {
+		out, err := r.cli.GetQueueUrl(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.urls[queue] = out.QueueUrl
+		r.mu.Unlock()
+
+		return out.QueueUrl, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting queue url for %s: %w", queue, err)
+	}
+
+	return resolved.(*string), nil
+}
+
+// input builds the queue url lookup request for the given queue name or ARN.
+func (r *sqsQueueResolver) input(queue string) (*sqs.GetQueueUrlInput, error) {
+	if !arn.IsARN(queue) {
+		in := sqs.GetQueueUrlInput{QueueName: aws.String(queue)}
+		if r.owner != "" {
+			in.QueueOwnerAWSAccountId = aws.String(r.owner)
+		}
+
+		return &in, nil
+	}
+
+	a, err := arn.Parse(queue)
+	if err != nil {
+		return nil, fmt.Errorf("parsing queue arn %s: %w", queue, err)
+	}
+	if a.Service != "sqs" || a.Resource == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidQueueARN, queue)
+	}
+	if region := r.region(); a.Region != "" && region != "" && a.Region != region {
+		return nil, fmt.Errorf("%w: %s, the client region is %s", ErrQueueRegionMismatch, queue, region)
+	}
+
+	in := sqs.GetQueueUrlInput{QueueName: aws.String(a.Resource)}
+	if a.AccountID != "" {
+		in.QueueOwnerAWSAccountId = aws.String(a.AccountID)
+	}
+
+	return &in, nil
+}
+
+// region returns the region of the client, empty if the client does not expose its options.
+func (r *sqsQueueResolver) region() string {
+	if cli, ok := r.cli.(interface{ Options() sqs.Options }); ok {
+		return cli.Options().Region
+	}
+
+	return ""
+}
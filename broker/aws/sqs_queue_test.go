@@ -0,0 +1,224 @@
+package aws_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	awsx "github.com/x4b1/messenger/broker/aws"
+)
+
+// regionalSQSClient exposes the client options like the SQS client does.
+type regionalSQSClient struct {
+	*SQSClientMock
+	region string
+}
+
+func (c regionalSQSClient) Options() sqs.Options {
+	return sqs.Options{Region: c.region}
+}
+
+func TestSQSSubscriber_QueueResolution(t *testing.T) {
+	const resolvedURL = "https://sqs.eu-west-1.amazonaws.com/210987654321/orders"
+
+	for _, tc := range []struct {
+		name          string
+		queue         string
+		opts          []awsx.SQSSubscriberOption
+		expectedInput *sqs.GetQueueUrlInput
+		expectedURL   string
+	}{
+		{
+			name:          "queue name",
+			queue:         "orders",
+			expectedInput: &sqs.GetQueueUrlInput{QueueName: aws.String("orders")},
+			expectedURL:   resolvedURL,
+		},
+		{
+			name:  "queue name with owner",
+			queue: "orders",
+			opts:  []awsx.SQSSubscriberOption{awsx.WithQueueOwner("210987654321")},
+			expectedInput: &sqs.GetQueueUrlInput{
+				QueueName:              aws.String("orders"),
+				QueueOwnerAWSAccountId: aws.String("210987654321"),
+			},
+			expectedURL: resolvedURL,
+		},
+		{
+			name:  "cross account queue arn",
+			queue: "arn:aws:sqs:eu-west-1:210987654321:orders",
+			opts:  []awsx.SQSSubscriberOption{awsx.WithQueueOwner("123456789012")},
+			expectedInput: &sqs.GetQueueUrlInput{
+				QueueName:              aws.String("orders"),
+				QueueOwnerAWSAccountId: aws.String("210987654321"),
+			},
+			expectedURL: resolvedURL,
+		},
+		{
+			name:        "queue url",
+			queue:       resolvedURL,
+			expectedURL: resolvedURL,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var (
+				mu       sync.Mutex
+				received []*sqs.ReceiveMessageInput
+			)
+			cli := &SQSClientMock{
+				GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+					return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(resolvedURL)}, nil
+				},
+				ReceiveMessageFunc: func(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+					mu.Lock()
+					received = append(received, in)
+					mu.Unlock()
+					cancel()
+
+					return &sqs.ReceiveMessageOutput{}, nil
+				},
+			}
+
+			s := awsx.NewSQSSubscriber(cli, tc.opts...)
+			s.Register(messenger.NewSubscription(tc.queue, nil))
+
+			require.NoError(t, s.Listen(ctx))
+
+			calls := cli.GetQueueUrlCalls()
+			if tc.expectedInput == nil {
+				require.Empty(t, calls)
+			} else {
+				require.Len(t, calls, 1)
+				require.Equal(t, tc.expectedInput, calls[0].GetQueueUrlInput)
+			}
+			require.NotEmpty(t, received)
+			require.Equal(t, tc.expectedURL, aws.ToString(received[0].QueueUrl))
+		})
+	}
+
+	t.Run("fails with non sqs arn", func(t *testing.T) {
+		cli := &SQSClientMock{}
+
+		s := awsx.NewSQSSubscriber(cli)
+		s.Register(messenger.NewSubscription("arn:aws:sns:eu-west-1:210987654321:orders", nil))
+
+		require.ErrorIs(t, s.Listen(context.Background()), awsx.ErrInvalidQueueARN)
+		require.Empty(t, cli.GetQueueUrlCalls())
+	})
+
+	t.Run("fails with queue arn of another region", func(t *testing.T) {
+		cli := &SQSClientMock{}
+
+		s := awsx.NewSQSSubscriber(regionalSQSClient{cli, "us-east-1"})
+		s.Register(messenger.NewSubscription("arn:aws:sqs:eu-west-1:210987654321:orders", nil))
+
+		require.ErrorIs(t, s.Listen(context.Background()), awsx.ErrQueueRegionMismatch)
+		require.Empty(t, cli.GetQueueUrlCalls())
+	})
+
+	t.Run("resolves dead letter queue", func(t *testing.T) {
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(_ context.Context, in *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return &sqs.GetQueueUrlOutput{
+					QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/210987654321/" + aws.ToString(in.QueueName)),
+				}, nil
+			},
+		}
+		listenOnce(t, failedMessage("3"), func(context.Context, messenger.Message) error {
+			return messenger.Permanent(errUnexpected)
+		}, cli, awsx.WithDeadLetterQueue("arn:aws:sqs:eu-west-1:210987654321:orders-dlq", 3))
+
+		sends := cli.SendMessageCalls()
+		require.Len(t, sends, 1)
+		require.Equal(t,
+			"https://sqs.eu-west-1.amazonaws.com/210987654321/orders-dlq",
+			aws.ToString(sends[0].SendMessageInput.QueueUrl),
+		)
+	})
+}
+
+func TestSQSPublisher_QueueResolution(t *testing.T) {
+	t.Parallel()
+
+	const resolvedURL = "https://sqs.eu-west-1.amazonaws.com/210987654321/orders"
+
+	t.Run("resolves queue arn once", func(t *testing.T) {
+		t.Parallel()
+
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(resolvedURL)}, nil
+			},
+			SendMessageFunc: func(context.Context, *sqs.SendMessageInput, ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				return &sqs.SendMessageOutput{}, nil
+			},
+		}
+
+		pub := awsx.NewSQSPublisher(cli, "arn:aws:sqs:eu-west-1:210987654321:orders")
+
+		require.NoError(t, pub.Publish(context.Background(), msg))
+		require.NoError(t, pub.Publish(context.Background(), msg))
+
+		calls := cli.GetQueueUrlCalls()
+		require.Len(t, calls, 1)
+		require.Equal(t, &sqs.GetQueueUrlInput{
+			QueueName:              aws.String("orders"),
+			QueueOwnerAWSAccountId: aws.String("210987654321"),
+		}, calls[0].GetQueueUrlInput)
+
+		for _, send := range cli.SendMessageCalls() {
+			require.Equal(t, resolvedURL, aws.ToString(send.SendMessageInput.QueueUrl))
+		}
+	})
+
+	t.Run("concurrent publishes share the lookup", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				<-release
+				return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(resolvedURL)}, nil
+			},
+			SendMessageFunc: func(context.Context, *sqs.SendMessageInput, ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				return &sqs.SendMessageOutput{}, nil
+			},
+		}
+		pub := awsx.NewSQSPublisher(regionalSQSClient{cli, "eu-west-1"}, "arn:aws:sqs:eu-west-1:210987654321:orders")
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() {
+				require.NoError(t, pub.Publish(context.Background(), msg))
+			})
+		}
+		require.Eventually(t, func() bool { return len(cli.GetQueueUrlCalls()) == 1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Len(t, cli.GetQueueUrlCalls(), 1)
+		require.Len(t, cli.SendMessageCalls(), 5)
+	})
+
+	t.Run("fails resolving queue", func(t *testing.T) {
+		t.Parallel()
+
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return nil, errAws
+			},
+		}
+
+		pub := awsx.NewSQSPublisher(cli, "orders")
+
+		require.ErrorIs(t, pub.Publish(context.Background(), msg), errAws)
+		require.Empty(t, cli.SendMessageCalls())
+	})
+}
@@ -3,7 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -15,11 +15,39 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// FailureReasonKey defines the message attribute key where the failure reason is sent
+// when a message is forwarded to the dead letter queue.
+const FailureReasonKey = "failure_reason"
+
+// Metadata keys where the SQS subscriber exposes the message system attributes when enabled.
+// All of them are under the reserved SQSMetadataPrefix.
+const (
+	SQSMetadataPrefix          = "sqs."
+	SQSMessageIDKey            = SQSMetadataPrefix + "message_id"
+	SQSReceiveCountKey         = SQSMetadataPrefix + "approximate_receive_count"
+	SQSSentTimestampKey        = SQSMetadataPrefix + "sent_timestamp"
+	SQSMessageGroupIDKey       = SQSMetadataPrefix + "message_group_id"
+	SQSMessageDeduplicationKey = SQSMetadataPrefix + "message_deduplication_id"
+)
+
+// sqsSystemAttributes maps the requested message system attributes to their metadata keys.
+//
+//nolint:gochecknoglobals // aws constant
+var sqsSystemAttributes = map[types.MessageSystemAttributeName]string{
+	types.MessageSystemAttributeNameApproximateReceiveCount: SQSReceiveCountKey,
+	types.MessageSystemAttributeNameSentTimestamp:           SQSSentTimestampKey,
+	types.MessageSystemAttributeNameMessageGroupId:          SQSMessageGroupIDKey,
+	types.MessageSystemAttributeNameMessageDeduplicationId:  SQSMessageDeduplicationKey,
+}
+
 const (
 	defaultMaxWaitSeconds  = 20
 	defaultReceiveMessages = 1
+	defaultConcurrency     = 1
 )
 
+var _ messenger.Subscriber = &SQSSubscriber{}
+
 // SQSSubscriberOption defines an interface for applying configuration options to SQSSubscriber instances.
 type SQSSubscriberOption interface {
 	applySQSSubscriber(*SQSSubscriber)
@@ -43,16 +71,17 @@ func NewSQSSubscriber(cli SQSClient, opts ...SQSSubscriberOption) *SQSSubscriber
 		cli:        cli,
 		subs:       make([]messenger.Subscription, 0),
 		errHandler: log.NewDefault(),
-		group:      new(errgroup.Group),
 
 		maxWaitSeconds: defaultMaxWaitSeconds,
 		maxMessages:    defaultReceiveMessages,
+		concurrency:    defaultConcurrency,
 		msgIDKey:       broker.MessageIDKey,
 	}
 
 	for _, opt := range opts {
 		opt.applySQSSubscriber(&s)
 	}
+	s.queues = newSQSQueueResolver(cli, s.queueOwner)
 
 	return &s
 }
@@ -61,14 +90,31 @@ func NewSQSSubscriber(cli SQSClient, opts ...SQSSubscriberOption) *SQSSubscriber
 // It handles receiving, processing, and deleting messages from SQS queues.
 type SQSSubscriber struct {
 	cli SQSClient
+	// resolves the subscriptions and dead letter queue urls.
+	queues     *sqsQueueResolver
+	queueOwner string
 
 	errHandler messenger.ErrorHandler
-	group      *errgroup.Group
 	subs       []messenger.Subscription
 
-	maxWaitSeconds int
-	maxMessages    int
-	msgIDKey       string
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	maxWaitSeconds    int
+	maxMessages       int
+	concurrency       int
+	maxInFlight       int
+	pollers           int
+	visibilityTimeout time.Duration
+	deleteBatchWindow time.Duration
+	heartbeat         VisibilityHeartbeatOption
+	backoff           BackoffOption
+	deadLetter        DeadLetterQueueOption
+	unwrapSNS         bool
+	systemAttributes  bool
+	msgIDKey          string
+	// attribute where the publisher packed the metadata exceeding the attributes limit.
+	overflowKey string
 }
 
 // Register adds one or more subscriptions to the SQSSubscriber.
@@ -77,50 +123,29 @@ func (s *SQSSubscriber) Register(subs ...messenger.Subscription) {
 	s.subs = append(s.subs, subs...)
 }
 
-// subscribe registers one subscription.
-func (s *SQSSubscriber) subscribe(ctx context.Context, sub messenger.Subscription) error {
-	arnSplit := strings.Split(sub.Name(), ":")
-
-	queueURL, err := s.cli.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
-		QueueName: aws.String(arnSplit[len(arnSplit)-1]),
-	})
+// subscribe registers one subscription, the subscription name is the queue name, ARN or url.
+func (s *SQSSubscriber) subscribe(
+	ctx, handleCtx context.Context,
+	group *errgroup.Group,
+	sub messenger.Subscription,
+) error {
+	queueURL, err := s.queues.resolve(ctx, sub.Name())
 	if err != nil {
-		return fmt.Errorf("getting queue url for %s: %w", sub.Name(), err)
+		return err
 	}
 
-	s.group.Go(func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			default:
-				msgs, err := s.cli.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-					QueueUrl:              queueURL.QueueUrl,
-					MaxNumberOfMessages:   int32(s.maxMessages),
-					WaitTimeSeconds:       int32(s.maxWaitSeconds),
-					MessageAttributeNames: []string{"All"},
-				})
-				if err != nil {
-					return fmt.Errorf("%s: %w", sub.Name(), err)
-				}
-
-				for _, msg := range msgs.Messages {
-					err := s.processMessage(ctx, sub, msg)
-					if err != nil {
-						s.errHandler.Error(ctx, err)
-						continue
-					}
-					if _, err := s.cli.DeleteMessage(ctx, &sqs.DeleteMessageInput{
-						ReceiptHandle: msg.ReceiptHandle,
-						QueueUrl:      queueURL.QueueUrl,
-					}); err != nil {
-						s.errHandler.Error(ctx, err)
-						continue
-					}
-				}
-			}
+	var deadLetterURL *string
+	if s.deadLetter.queue != "" {
+		if deadLetterURL, err = s.queues.resolve(ctx, s.deadLetter.queue); err != nil {
+			return err
 		}
+	}
+
+	c := newSQSConsumer(s, sub, queueURL, deadLetterURL)
+	group.Go(func() error {
+		return c.run(ctx, handleCtx)
 	})
+
 	return nil
 }
 
@@ -129,33 +154,78 @@ func (s *SQSSubscriber) processMessage(
 	sub messenger.Subscription,
 	msg types.Message,
 ) error {
-	parsed := messenger.GenericMessage{
-		MsgPayload: []byte(aws.ToString(msg.Body)),
-	}
+	return sub.Handle(ctx, s.parseMessage(msg))
+}
+
+// parseMessage transforms the SQS message into a messenger message, restoring the message id
+// from the attributes, unwrapping the SNS envelope and unpacking the overflow attribute if enabled.
+func (s *SQSSubscriber) parseMessage(msg types.Message) *messenger.GenericMessage {
+	body := aws.ToString(msg.Body)
+	msgID := aws.ToString(msg.MessageId)
 
-	parsed.MsgMetadata = make(map[string]string, len(msg.MessageAttributes))
+	att := make(map[string]string, len(msg.MessageAttributes))
 	for k, v := range msg.MessageAttributes {
+		att[k] = attributeValue(v)
+	}
+
+	if s.unwrapSNS {
+		if n, ok := parseSNSNotification(body); ok {
+			body = n.Message
+			msgID = n.MessageID
+			att = n.attributes()
+		}
+	}
+	unpackAttributes(att, s.overflowKey)
+
+	parsed := messenger.GenericMessage{
+		MsgPayload:  []byte(body),
+		MsgMetadata: make(map[string]string, len(att)),
+	}
+	for k, v := range att {
 		if k == s.msgIDKey {
-			parsed.MsgID = aws.ToString(v.StringValue)
+			parsed.MsgID = v
 			continue
 		}
-		parsed.MsgMetadata[k] = aws.ToString(v.StringValue)
+		parsed.MsgMetadata[k] = v
 	}
 	if parsed.MsgID == "" {
-		parsed.MsgID = aws.ToString(msg.MessageId)
+		parsed.MsgID = msgID
 	}
 
-	return sub.Handle(ctx, &parsed)
+	if s.systemAttributes {
+		parsed.MsgMetadata[SQSMessageIDKey] = aws.ToString(msg.MessageId)
+		for name, key := range sqsSystemAttributes {
+			if v, ok := msg.Attributes[string(name)]; ok {
+				parsed.MsgMetadata[key] = v
+			}
+		}
+	}
+
+	return &parsed
 }
 
 // Listen starts the message polling and processing loop for all registered subscriptions.
 // It blocks until all subscription goroutines have finished or an error occurs.
+// Once the context is cancelled it stops receiving messages and returns after
+// the messages being processed finish, handlers are not cancelled until Shutdown grace period ends.
 func (s *SQSSubscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen runs a consumer for every registered subscription until all of them finish.
+func (s *SQSSubscriber) listen(ctx, handleCtx context.Context) error {
+	ctx, stopReceiving := context.WithCancel(ctx)
+	defer stopReceiving()
+
+	group := new(errgroup.Group)
 	for _, sub := range s.subs {
-		if err := s.subscribe(ctx, sub); err != nil {
+		if err := s.subscribe(ctx, handleCtx, group, sub); err != nil {
+			stopReceiving()
+			_ = group.Wait()
+
 			return err
 		}
 	}
 
-	return s.group.Wait()
+	return group.Wait()
 }
@@ -3,7 +3,9 @@ package aws_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -264,4 +266,428 @@ func TestSQSSubscriber_Subscribe(t *testing.T) {
 
 		require.Equal(t, "custom_id", hID)
 	})
+
+	t.Run("Close stops listening", func(t *testing.T) {
+		testSub := messenger.NewSubscription("test", nil)
+
+		receiving := make(chan struct{})
+		var once sync.Once
+
+		s := awsx.NewSQSSubscriber(&SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return queueURLOut, nil
+			},
+			ReceiveMessageFunc: func(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+				once.Do(func() { close(receiving) })
+				<-ctx.Done()
+
+				return &sqs.ReceiveMessageOutput{}, nil
+			},
+		})
+		s.Register(testSub)
+
+		listenErr := make(chan error)
+		go func() { listenErr <- s.Listen(context.Background()) }()
+
+		<-receiving
+		require.NoError(t, s.Close(context.Background()))
+		require.NoError(t, <-listenErr)
+	})
+
+	t.Run("Close without listening does nothing", func(t *testing.T) {
+		require.NoError(t, awsx.NewSQSSubscriber(&SQSClientMock{}).Close(context.Background()))
+	})
+
+	t.Run("processes messages concurrently", func(t *testing.T) {
+		const workers = 3
+
+		var (
+			mu      sync.Mutex
+			running int
+			maxRun  int
+		)
+		release := make(chan struct{})
+
+		testSub := messenger.NewSubscription(
+			"test",
+			func(context.Context, messenger.Message) error {
+				mu.Lock()
+				running++
+				maxRun = max(maxRun, running)
+				if running == workers {
+					close(release)
+				}
+				mu.Unlock()
+
+				<-release
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+
+				return nil
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		s := awsx.NewSQSSubscriber(
+			&SQSClientMock{
+				GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+					return queueURLOut, nil
+				},
+				ReceiveMessageFunc: func(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+					cancel()
+
+					out := &sqs.ReceiveMessageOutput{}
+					for range in.MaxNumberOfMessages {
+						out.Messages = append(out.Messages, message.Messages[0])
+					}
+
+					return out, nil
+				},
+			},
+			awsx.WithMaxMessages(workers),
+			awsx.WithConcurrency(workers),
+		)
+		s.Register(testSub)
+
+		require.NoError(t, s.Listen(ctx))
+		require.Equal(t, workers, maxRun)
+	})
+
+	t.Run("receives while workers are busy within max in flight", func(t *testing.T) {
+		const maxInFlight = 4
+
+		release := make(chan struct{})
+		testSub := messenger.NewSubscription(
+			"test",
+			func(context.Context, messenger.Message) error {
+				<-release
+				return nil
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var (
+			mu       sync.Mutex
+			received int32
+			requests []int32
+		)
+
+		s := awsx.NewSQSSubscriber(
+			&SQSClientMock{
+				GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+					return queueURLOut, nil
+				},
+				ReceiveMessageFunc: func(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					requests = append(requests, in.MaxNumberOfMessages)
+					received += in.MaxNumberOfMessages
+					if received >= maxInFlight {
+						cancel()
+						close(release)
+					}
+
+					out := &sqs.ReceiveMessageOutput{}
+					for range in.MaxNumberOfMessages {
+						out.Messages = append(out.Messages, message.Messages[0])
+					}
+
+					return out, nil
+				},
+			},
+			awsx.WithMaxMessages(3),
+			awsx.WithMaxInFlight(maxInFlight),
+		)
+		s.Register(testSub)
+
+		require.NoError(t, s.Listen(ctx))
+		require.Equal(t, []int32{3, 1}, requests)
+	})
+
+	t.Run("extends visibility while handling", func(t *testing.T) {
+		testSub := messenger.NewSubscription(
+			"test",
+			func(context.Context, messenger.Message) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		cli := &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return queueURLOut, nil
+			},
+			ReceiveMessageFunc: func(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+				cancel()
+
+				return message, nil
+			},
+		}
+		s := awsx.NewSQSSubscriber(cli, awsx.WithVisibilityHeartbeat(10*time.Millisecond, time.Minute))
+		s.Register(testSub)
+
+		require.NoError(t, s.Listen(ctx))
+
+		calls := cli.ChangeMessageVisibilityCalls()
+		require.GreaterOrEqual(t, len(calls), 2)
+		for _, c := range calls {
+			require.Equal(t, queueURLOut.QueueUrl, c.ChangeMessageVisibilityInput.QueueUrl)
+			require.Equal(t, message.Messages[0].ReceiptHandle, c.ChangeMessageVisibilityInput.ReceiptHandle)
+			require.Equal(t, int32(60), c.ChangeMessageVisibilityInput.VisibilityTimeout)
+		}
+
+		time.Sleep(30 * time.Millisecond)
+		require.Len(t, cli.ChangeMessageVisibilityCalls(), len(calls))
+	})
+}
+
+func TestSQSSubscriber_SNSEnvelope(t *testing.T) {
+	envelope := `{
+		"Type": "Notification",
+		"MessageId": "` + awsMessageID + `",
+		"TopicArn": "arn:aws:sns:eu-west-1:123456789012:test-topic",
+		"Message": "{\"hello\":\"world\"}",
+		"Timestamp": "2024-01-01T00:00:00.000Z",
+		"MessageAttributes": {
+			"AN": {"Type": "String", "Value": "ATTRIBUTE"},
+			"BIN": {"Type": "Binary", "Value": "aGVsbG8="},
+			"SIG": {"Type": "Binary.sig", "Value": "d29ybGQ="},
+			"` + broker.MessageIDKey + `": {"Type": "String", "Value": "` + customMsgID + `"}
+		}
+	}`
+	out := &sqs.ReceiveMessageOutput{
+		Messages: []types.Message{
+			{MessageId: aws.String("sqs-message-id"), Body: aws.String(envelope)},
+		},
+	}
+
+	t.Run("unwraps envelope", func(t *testing.T) {
+		var got messenger.Message
+		listenOnce(t, out, func(_ context.Context, msg messenger.Message) error {
+			got = msg
+			return nil
+		}, &SQSClientMock{}, awsx.WithSNSEnvelope(true))
+
+		require.Equal(t, customMsgID, got.ID())
+		require.JSONEq(t, `{"hello":"world"}`, string(got.Payload()))
+		require.Equal(t, messenger.Metadata{"AN": "ATTRIBUTE", "BIN": "hello", "SIG": "world"}, got.Metadata())
+	})
+
+	t.Run("without message id key uses sns message id", func(t *testing.T) {
+		var got messenger.Message
+		listenOnce(t, out, func(_ context.Context, msg messenger.Message) error {
+			got = msg
+			return nil
+		}, &SQSClientMock{}, awsx.WithSNSEnvelope(true), awsx.WithMessageIDKey("custom_key"))
+
+		require.Equal(t, awsMessageID, got.ID())
+		require.Equal(t, customMsgID, got.Metadata().Get(broker.MessageIDKey))
+	})
+
+	t.Run("disabled keeps envelope", func(t *testing.T) {
+		var got messenger.Message
+		listenOnce(t, out, func(_ context.Context, msg messenger.Message) error {
+			got = msg
+			return nil
+		}, &SQSClientMock{})
+
+		require.Equal(t, "sqs-message-id", got.ID())
+		require.Equal(t, envelope, string(got.Payload()))
+	})
+
+	t.Run("not an envelope keeps body", func(t *testing.T) {
+		var got messenger.Message
+		listenOnce(t, message, func(_ context.Context, msg messenger.Message) error {
+			got = msg
+			return nil
+		}, &SQSClientMock{}, awsx.WithSNSEnvelope(true))
+
+		require.Equal(t, customMsgID, got.ID())
+		require.Equal(t, "hello world", string(got.Payload()))
+		require.Equal(t, messenger.Metadata{"AN": "ATTRIBUTE"}, got.Metadata())
+	})
+}
+
+func TestSQSSubscriber_SystemAttributes(t *testing.T) {
+	out := &sqs.ReceiveMessageOutput{
+		Messages: []types.Message{
+			{
+				MessageId: aws.String(awsMessageID),
+				Body:      aws.String("hello world"),
+				Attributes: map[string]string{
+					string(types.MessageSystemAttributeNameApproximateReceiveCount): "2",
+					string(types.MessageSystemAttributeNameSentTimestamp):           "1704067200000",
+					string(types.MessageSystemAttributeNameMessageGroupId):          "group-1",
+					string(types.MessageSystemAttributeNameMessageDeduplicationId):  "dedup-1",
+				},
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"AN": {StringValue: aws.String("ATTRIBUTE")},
+				},
+			},
+		},
+	}
+
+	t.Run("exposes system attributes as metadata", func(t *testing.T) {
+		var got messenger.Metadata
+		cli := &SQSClientMock{}
+		listenOnce(t, out, func(_ context.Context, msg messenger.Message) error {
+			got = msg.Metadata()
+			return nil
+		}, cli, awsx.WithSystemAttributes(true))
+
+		require.Equal(t, messenger.Metadata{
+			"AN":                            "ATTRIBUTE",
+			awsx.SQSMessageIDKey:            awsMessageID,
+			awsx.SQSReceiveCountKey:         "2",
+			awsx.SQSSentTimestampKey:        "1704067200000",
+			awsx.SQSMessageGroupIDKey:       "group-1",
+			awsx.SQSMessageDeduplicationKey: "dedup-1",
+		}, got)
+
+		require.ElementsMatch(t, []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameSentTimestamp,
+			types.MessageSystemAttributeNameMessageGroupId,
+			types.MessageSystemAttributeNameMessageDeduplicationId,
+		}, cli.ReceiveMessageCalls()[0].ReceiveMessageInput.MessageSystemAttributeNames)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		var got messenger.Metadata
+		listenOnce(t, out, func(_ context.Context, msg messenger.Message) error {
+			got = msg.Metadata()
+			return nil
+		}, &SQSClientMock{})
+
+		require.Equal(t, messenger.Metadata{"AN": "ATTRIBUTE"}, got)
+	})
+}
+
+func TestSQSSubscriber_Shutdown(t *testing.T) {
+	newClient := func(received chan<- struct{}) *SQSClientMock {
+		var once sync.Once
+
+		return &SQSClientMock{
+			GetQueueUrlFunc: func(context.Context, *sqs.GetQueueUrlInput, ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+				return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/12345/test")}, nil
+			},
+			ReceiveMessageFunc: func(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+				first := false
+				once.Do(func() { first = true })
+				if first {
+					close(received)
+					return message, nil
+				}
+				<-ctx.Done()
+
+				return nil, ctx.Err()
+			},
+		}
+	}
+
+	t.Run("drains in flight messages", func(t *testing.T) {
+		received := make(chan struct{})
+		release := make(chan struct{})
+		var handlerCtxErr error
+
+		cli := newClient(received)
+		s := awsx.NewSQSSubscriber(cli)
+		s.Register(messenger.NewSubscription("test", func(ctx context.Context, _ messenger.Message) error {
+			<-release
+			handlerCtxErr = ctx.Err()
+			return nil
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		listenErr := make(chan error)
+		go func() { listenErr <- s.Listen(ctx) }()
+
+		<-received
+		cancel()
+
+		shutdownErr := make(chan error)
+		go func() { shutdownErr <- s.Shutdown(context.Background()) }()
+
+		close(release)
+		require.NoError(t, <-shutdownErr)
+		require.NoError(t, <-listenErr)
+
+		require.NoError(t, handlerCtxErr)
+		require.Len(t, cli.DeleteMessageCalls(), 1)
+		require.NoError(t, cli.DeleteMessageCalls()[0].ContextMoqParam.Err())
+	})
+
+	t.Run("grace period ends cancels handlers", func(t *testing.T) {
+		received := make(chan struct{})
+
+		cli := newClient(received)
+		s := awsx.NewSQSSubscriber(cli)
+		s.Register(messenger.NewSubscription("test", func(ctx context.Context, _ messenger.Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		listenErr := make(chan error)
+		go func() { listenErr <- s.Listen(context.Background()) }()
+
+		<-received
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		require.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+		require.NoError(t, <-listenErr)
+		require.Empty(t, cli.DeleteMessageCalls())
+	})
+}
+
+func TestSQSSubscriber_Attributes(t *testing.T) {
+	t.Run("restores binary attributes", func(t *testing.T) {
+		var got messenger.Metadata
+		listenOnce(t, &sqs.ReceiveMessageOutput{
+			Messages: []types.Message{{
+				MessageId: aws.String(awsMessageID),
+				Body:      aws.String("hello world"),
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"AN":  {DataType: aws.String("Number"), StringValue: aws.String("1")},
+					"BIN": {DataType: aws.String("Binary"), BinaryValue: []byte("hello")},
+				},
+			}},
+		}, func(_ context.Context, msg messenger.Message) error {
+			got = msg.Metadata()
+			return nil
+		}, &SQSClientMock{})
+
+		require.Equal(t, messenger.Metadata{"AN": "1", "BIN": "hello"}, got)
+	})
+
+	t.Run("unpacks overflow attribute from sns envelope", func(t *testing.T) {
+		envelope := `{
+			"Type": "Notification",
+			"MessageId": "` + awsMessageID + `",
+			"TopicArn": "arn:aws:sns:eu-west-1:123456789012:test-topic",
+			"Message": "hello world",
+			"MessageAttributes": {
+				"AN": {"Type": "String", "Value": "ATTRIBUTE"},
+				"overflow": {"Type": "String", "Value": "{\"AN\":\"ignored\",\"packed\":\"value\"}"}
+			}
+		}`
+
+		var got messenger.Metadata
+		listenOnce(t, &sqs.ReceiveMessageOutput{
+			Messages: []types.Message{{MessageId: aws.String("sqs-message-id"), Body: aws.String(envelope)}},
+		}, func(_ context.Context, msg messenger.Message) error {
+			got = msg.Metadata()
+			return nil
+		}, &SQSClientMock{}, awsx.WithSNSEnvelope(true), awsx.WithOverflowAttribute("overflow"))
+
+		require.Equal(t, messenger.Metadata{"AN": "ATTRIBUTE", "packed": "value"}, got)
+	})
 }
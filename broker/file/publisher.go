@@ -0,0 +1,250 @@
+// Package file JSON lines file broker implementation, to keep audit trails of the published messages
+// and replay them later.
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const (
+	filePerm       = 0o600
+	gzipExt        = ".gz"
+	rotatedTimeFmt = "20060102T150405.000000000"
+)
+
+var _ broker.Broker = &Publisher{}
+
+// Option is a function to set options to Publisher.
+type Option func(*Publisher)
+
+// WithMaxSize setups the size in bytes a file can reach before rotating it, zero disables it.
+func WithMaxSize(size int64) Option {
+	return func(p *Publisher) {
+		p.maxSize = size
+	}
+}
+
+// WithMaxAge setups the time a file is written before rotating it, zero disables it.
+func WithMaxAge(d time.Duration) Option {
+	return func(p *Publisher) {
+		p.maxAge = d
+	}
+}
+
+// WithGzip enables or disables compressing the rotated files with gzip.
+func WithGzip(enabled bool) Option {
+	return func(p *Publisher) {
+		p.gzip = enabled
+	}
+}
+
+// WithErrorHandler replaces the default error logger, used to report the files that fail to rotate.
+func WithErrorHandler(h messenger.ErrorHandler) Option {
+	return func(p *Publisher) {
+		p.errHandler = h
+	}
+}
+
+// Open returns a new Publisher instance appending the messages to the file in the given path,
+// it is created if it does not exist.
+func Open(path string, opts ...Option) (*Publisher, error) {
+	p := Publisher{
+		path:       path,
+		errHandler: log.NewDefault(),
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Publisher appends the messages to a file as JSON lines encoded by messenger.GenericMessage MarshalJSON,
+// with the message id, metadata, payload, published flag and creation time, so the Reader restores the exact bytes.
+// Once the file reaches the max size or age it is renamed adding its rotation time to the name,
+// ex: audit-20250102T030405.000000000.jsonl, and optionally compressed, and a new file is started.
+// Failing to rotate the file is reported to the error handler, and the messages keep being appended
+// to the file in the same path.
+type Publisher struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	gzip       bool
+	errHandler messenger.ErrorHandler
+
+	mu       sync.Mutex
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+}
+
+// Publish appends the message to the file, rotating it first if needed.
+func (p *Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	line, err := (&messenger.GenericMessage{
+		MsgID:        msg.ID(),
+		MsgMetadata:  msg.Metadata(),
+		MsgPayload:   msg.Payload(),
+		MsgPublished: msg.Published(),
+		MsgAt:        msg.At(),
+	}).MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return os.ErrClosed
+	}
+
+	if p.file != nil && p.mustRotate(int64(len(line))) {
+		if err := p.rotate(); err != nil {
+			p.errHandler.Error(ctx, fmt.Errorf("rotating file: %w", err))
+		}
+	}
+	// the file is opened again once rotated, or if it failed to open before.
+	if p.file == nil {
+		if err := p.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := p.file.Write(line)
+	p.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the current file.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+
+	return err
+}
+
+// mustRotate reports whether the file has to be rotated before writing the given bytes,
+// empty files are never rotated.
+func (p *Publisher) mustRotate(n int64) bool {
+	if p.size == 0 {
+		return false
+	}
+
+	return (p.maxSize > 0 && p.size+n > p.maxSize) ||
+		(p.maxAge > 0 && time.Since(p.openedAt) >= p.maxAge)
+}
+
+// open opens the file for appending, keeping its current size.
+func (p *Publisher) open() error {
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		//nolint:errcheck // already failing.
+		f.Close()
+
+		return fmt.Errorf("reading file info: %w", err)
+	}
+
+	p.file = f
+	p.size = info.Size()
+	p.openedAt = time.Now()
+
+	return nil
+}
+
+// rotate closes the current file and renames it, compressing it if enabled,
+// the file has to be opened again even if it fails. If compressing fails the rotated file is kept uncompressed.
+func (p *Publisher) rotate() error {
+	err := p.file.Close()
+	p.file = nil
+	if err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	rotated := rotatedPath(p.path, time.Now())
+	if err := os.Rename(p.path, rotated); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	if p.gzip {
+		if err := compress(rotated); err != nil {
+			return fmt.Errorf("compressing file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rotatedPath returns the path of the file rotated at the given time, adding the time before the extension.
+func rotatedPath(path string, at time.Time) string {
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "-" + at.UTC().Format(rotatedTimeFmt) + ext
+}
+
+// compress replaces the file with its gzip compressed version,
+// if it fails the file is kept and the partially written compressed file removed.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // read only file.
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+gzipExt, os.O_CREATE|os.O_WRONLY|os.O_EXCL, filePerm)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		//nolint:errcheck // already failing.
+		os.Remove(path + gzipExt)
+
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,246 @@
+package file_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/file"
+)
+
+func newMessages(t *testing.T, n int) []*messenger.GenericMessage {
+	t.Helper()
+
+	msgs := make([]*messenger.GenericMessage, 0, n)
+	for i := range n {
+		msg, err := messenger.NewMessage(fmt.Appendf(nil, `{"number":%d}`, i))
+		require.NoError(t, err)
+		msg.SetMetadata("number", fmt.Sprint(i))
+		// a fixed creation time keeps the same line size for every message.
+		msg.MsgAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+func publish(t *testing.T, p *file.Publisher, msgs ...*messenger.GenericMessage) {
+	t.Helper()
+
+	for _, msg := range msgs {
+		require.NoError(t, p.Publish(context.Background(), msg))
+	}
+}
+
+// rotated returns the rotated files in the directory sorted by name.
+func rotated(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+	sort.Strings(files)
+
+	return files
+}
+
+// lines returns the file lines, decompressing it if it is gzipped.
+func lines(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	//nolint:errcheck // test file
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	if filepath.Ext(path) == ".gz" {
+		zr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		sc = bufio.NewScanner(zr)
+	}
+
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	require.NoError(t, sc.Err())
+
+	return lines
+}
+
+// lineSize returns the size of the line written for the message.
+func lineSize(t *testing.T, msg *messenger.GenericMessage) int64 {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "line.jsonl")
+	p, err := file.Open(path)
+	require.NoError(t, err)
+	publish(t, p, msg)
+	require.NoError(t, p.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	return info.Size()
+}
+
+// errorRecorder is an error handler that keeps the reported errors.
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *errorRecorder) Error(_ context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("appends json lines", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		msgs := newMessages(t, 3)
+
+		p, err := file.Open(path)
+		require.NoError(t, err)
+		publish(t, p, msgs[:2]...)
+		require.NoError(t, p.Close())
+
+		// reopening keeps appending to the same file.
+		p, err = file.Open(path)
+		require.NoError(t, err)
+		publish(t, p, msgs[2])
+		require.NoError(t, p.Close())
+
+		got := lines(t, path)
+		require.Len(t, got, len(msgs))
+		for i, msg := range msgs {
+			expected, err := json.Marshal(map[string]any{
+				"id":               msg.ID(),
+				"metadata":         msg.Metadata(),
+				"payload_encoding": "json",
+				"payload":          json.RawMessage(msg.Payload()),
+				"published":        false,
+				"at":               msg.At(),
+			})
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), got[i])
+		}
+	})
+
+	t.Run("fails once closed", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := file.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+		require.NoError(t, err)
+		require.NoError(t, p.Close())
+
+		require.ErrorIs(t, p.Publish(context.Background(), newMessages(t, 1)[0]), os.ErrClosed)
+	})
+
+	t.Run("fails opening file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := file.Open(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("rotates by size", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.jsonl")
+		msgs := newMessages(t, 5)
+
+		// every file fits two messages.
+		p, err := file.Open(path, file.WithMaxSize(2*lineSize(t, msgs[0])))
+		require.NoError(t, err)
+		publish(t, p, msgs...)
+		require.NoError(t, p.Close())
+
+		files := rotated(t, dir, "audit-*.jsonl")
+		require.Len(t, files, 2)
+		require.Len(t, lines(t, files[0]), 2)
+		require.Len(t, lines(t, files[1]), 2)
+		require.Len(t, lines(t, path), 1)
+	})
+
+	t.Run("rotates by age", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.jsonl")
+		msgs := newMessages(t, 2)
+
+		p, err := file.Open(path, file.WithMaxAge(10*time.Millisecond))
+		require.NoError(t, err)
+		publish(t, p, msgs[0])
+		time.Sleep(20 * time.Millisecond)
+		publish(t, p, msgs[1])
+		require.NoError(t, p.Close())
+
+		files := rotated(t, dir, "audit-*.jsonl")
+		require.Len(t, files, 1)
+		require.Len(t, lines(t, files[0]), 1)
+		require.Len(t, lines(t, path), 1)
+	})
+
+	t.Run("compresses rotated files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.jsonl")
+		msgs := newMessages(t, 3)
+
+		p, err := file.Open(path, file.WithMaxSize(1), file.WithGzip(true))
+		require.NoError(t, err)
+		publish(t, p, msgs...)
+		require.NoError(t, p.Close())
+
+		require.Empty(t, rotated(t, dir, "audit-*.jsonl"))
+		files := rotated(t, dir, "audit-*.jsonl.gz")
+		require.Len(t, files, 2)
+		for _, f := range files {
+			require.Len(t, lines(t, f), 1)
+		}
+	})
+
+	t.Run("keeps appending when rotation fails", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.jsonl")
+		msgs := newMessages(t, 3)
+
+		errs := &errorRecorder{}
+		p, err := file.Open(path, file.WithMaxSize(1), file.WithErrorHandler(errs))
+		require.NoError(t, err)
+		publish(t, p, msgs[0])
+
+		// the file to rotate is removed, so renaming it fails.
+		require.NoError(t, os.Remove(path))
+		publish(t, p, msgs[1:]...)
+		require.NoError(t, p.Close())
+
+		require.Len(t, errs.errs, 1)
+		require.ErrorIs(t, errs.errs[0], os.ErrNotExist)
+		files := rotated(t, dir, "audit-*.jsonl")
+		require.Len(t, files, 1)
+		require.Len(t, lines(t, files[0]), 1)
+		require.Len(t, lines(t, path), 1)
+	})
+}
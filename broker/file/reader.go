@@ -0,0 +1,117 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/x4b1/messenger"
+)
+
+// gzipMagic are the first bytes of gzip compressed content.
+var gzipMagic = []byte{0x1f, 0x8b} //nolint:gochecknoglobals // gzip constant
+
+// OpenReader returns a new Reader of the file in the given path.
+func OpenReader(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	r, err := NewReader(f)
+	if err != nil {
+		//nolint:errcheck // already failing.
+		f.Close()
+
+		return nil, err
+	}
+	r.closer = f
+
+	return r, nil
+}
+
+// NewReader returns a new Reader of the messages written by a Publisher,
+// gzip compressed content is decompressed.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return &Reader{r: br}, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip file: %w", err)
+	}
+
+	return &Reader{r: bufio.NewReader(zr)}, nil
+}
+
+// Reader reads the messages written by a Publisher, to replay them.
+type Reader struct {
+	r *bufio.Reader
+	// file opened by the reader, nil if provided by the caller.
+	closer io.Closer
+}
+
+// Next returns the next message, or io.EOF once all messages have been read.
+func (r *Reader) Next() (*messenger.GenericMessage, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		var msg messenger.GenericMessage
+		if err := msg.UnmarshalJSON(line); err != nil {
+			return nil, fmt.Errorf("decoding message: %w", err)
+		}
+
+		return &msg, nil
+	}
+}
+
+// Replay reads all the messages and handles them in order with the given handler, ex: a
+// messenger.Publisher Publish or messenger.Subscription Handle method. It stops on the first error.
+func (r *Reader) Replay(ctx context.Context, h messenger.SubscriptionHandler) error {
+	for ctx.Err() == nil {
+		msg, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := h(ctx, msg); err != nil {
+			return fmt.Errorf("replaying message %s: %w", msg.ID(), err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Close closes the file if it was opened by the Reader.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+
+	return r.closer.Close()
+}
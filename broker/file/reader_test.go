@@ -0,0 +1,211 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/file"
+	"github.com/x4b1/messenger/broker/memory"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replays rotated and current files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.jsonl")
+		msgs := newMessages(t, 3)
+		msgs[2].MsgPayload = []byte("not json")
+
+		p, err := file.Open(path, file.WithMaxSize(1), file.WithGzip(true))
+		require.NoError(t, err)
+		publish(t, p, msgs...)
+		require.NoError(t, p.Close())
+
+		var replayed []messenger.Message
+		sub := messenger.NewSubscription("replay", func(_ context.Context, msg messenger.Message) error {
+			replayed = append(replayed, msg)
+
+			return nil
+		})
+		for _, path := range append(rotated(t, dir, "audit-*.jsonl.gz"), path) {
+			r, err := file.OpenReader(path)
+			require.NoError(t, err)
+			require.NoError(t, r.Replay(context.Background(), sub.Handle))
+			require.NoError(t, r.Close())
+		}
+
+		require.Len(t, replayed, len(msgs))
+		for i, msg := range msgs {
+			require.Equal(t, msg.ID(), replayed[i].ID())
+			require.Equal(t, msg.Metadata(), replayed[i].Metadata())
+			require.Equal(t, msg.Payload(), replayed[i].Payload())
+			require.True(t, msg.At().Equal(replayed[i].At()))
+		}
+	})
+
+	t.Run("restores exact payloads", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		payloads := []string{
+			`"json string"`,
+			"{ \"formatted\": [1, 2] }\n",
+			`{"html":"<a href=\"x\">&</a>","unicode":"\u00e9"}`,
+			"not json",
+			"\x00\xff binary",
+		}
+
+		p, err := file.Open(path)
+		require.NoError(t, err)
+		for _, payload := range payloads {
+			msg, err := messenger.NewMessage([]byte(payload))
+			require.NoError(t, err)
+			publish(t, p, msg)
+		}
+		require.NoError(t, p.Close())
+
+		r, err := file.OpenReader(path)
+		require.NoError(t, err)
+		//nolint:errcheck // test file
+		defer r.Close()
+		for _, payload := range payloads {
+			msg, err := r.Next()
+			require.NoError(t, err)
+			require.Equal(t, payload, string(msg.Payload()))
+		}
+		_, err = r.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("replays through a publisher", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		msgs := newMessages(t, 2)
+
+		p, err := file.Open(path)
+		require.NoError(t, err)
+		publish(t, p, msgs...)
+		require.NoError(t, p.Close())
+
+		b := memory.New("orders")
+		s := memory.NewSubscriber(b)
+		received := make(chan messenger.Message, len(msgs))
+		s.Register(messenger.NewSubscription("orders", func(_ context.Context, msg messenger.Message) error {
+			received <- msg
+
+			return nil
+		}))
+
+		r, err := file.OpenReader(path)
+		require.NoError(t, err)
+		//nolint:errcheck // test file
+		defer r.Close()
+		require.NoError(t, r.Replay(context.Background(), b.Publish))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go s.Listen(ctx) //nolint:errcheck // test file
+
+		for _, msg := range msgs {
+			select {
+			case <-ctx.Done():
+				require.FailNow(t, "message not received")
+			case got := <-received:
+				require.Equal(t, msg.ID(), got.ID())
+			}
+		}
+	})
+
+	t.Run("stops on handler error", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		msgs := newMessages(t, 3)
+
+		p, err := file.Open(path)
+		require.NoError(t, err)
+		publish(t, p, msgs...)
+		require.NoError(t, p.Close())
+
+		errHandler := errors.New("handler error")
+		var handled int
+		r, err := file.OpenReader(path)
+		require.NoError(t, err)
+		//nolint:errcheck // test file
+		defer r.Close()
+
+		err = r.Replay(context.Background(), func(_ context.Context, msg messenger.Message) error {
+			handled++
+			if msg.ID() == msgs[1].ID() {
+				return errHandler
+			}
+
+			return nil
+		})
+		require.ErrorIs(t, err, errHandler)
+		require.ErrorContains(t, err, msgs[1].ID())
+		require.Equal(t, 2, handled)
+	})
+
+	t.Run("skips empty lines", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := file.NewReader(strings.NewReader("\n" +
+			`{"id":"1","metadata":{},"payload_encoding":"json","payload":"a"}` + "\n\n" +
+			`{"id":"2","metadata":{},"payload_encoding":"base64","payload":"Yg=="}`,
+		))
+		require.NoError(t, err)
+
+		for _, expected := range []struct{ id, payload string }{{"1", `"a"`}, {"2", "b"}} {
+			msg, err := r.Next()
+			require.NoError(t, err)
+			require.Equal(t, expected.id, msg.ID())
+			require.Equal(t, expected.payload, string(msg.Payload()))
+		}
+		_, err = r.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	for name, line := range map[string]string{
+		"invalid line":             "not json\n",
+		"unknown payload encoding": `{"id":"1","metadata":{},"payload_encoding":"hex","payload":"61"}` + "\n",
+		"invalid base64 payload":   `{"id":"1","metadata":{},"payload_encoding":"base64","payload":"not base64"}` + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := file.NewReader(strings.NewReader(line))
+			require.NoError(t, err)
+
+			require.Error(t, r.Replay(context.Background(), func(context.Context, messenger.Message) error { return nil }))
+		})
+	}
+
+	t.Run("empty file", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := file.NewReader(strings.NewReader(""))
+		require.NoError(t, err)
+
+		require.NoError(t, r.Replay(context.Background(), func(context.Context, messenger.Message) error { return nil }))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := file.OpenReader(filepath.Join(t.TempDir(), "audit.jsonl"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,40 @@
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const topic = "test-topic"
+
+// newCluster starts an in memory kafka cluster with the test topic and returns its brokers addresses.
+func newCluster(t *testing.T, partitions int32) []string {
+	t.Helper()
+
+	return newFakeCluster(t, partitions).ListenAddrs()
+}
+
+// newFakeCluster starts an in memory kafka cluster with the test topic.
+func newFakeCluster(t *testing.T, partitions int32) *kfake.Cluster {
+	t.Helper()
+
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(partitions, topic))
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// newClient returns a kafka client connected to the given brokers.
+func newClient(t *testing.T, seeds []string, opts ...kgo.Opt) *kgo.Client {
+	t.Helper()
+
+	cl, err := kgo.NewClient(append([]kgo.Opt{kgo.SeedBrokers(seeds...)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(cl.Close)
+
+	return cl
+}
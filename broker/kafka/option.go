@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// PublisherOption defines an interface for applying configuration options to Publisher instances.
+type PublisherOption interface {
+	applyPublisher(*Publisher)
+}
+
+// SubscriberOption defines an interface for applying configuration options to Subscriber instances.
+type SubscriberOption interface {
+	applySubscriber(*Subscriber)
+}
+
+// WithMetaOrderingKey returns an option to configure the metadata key to get the partition key of the Publisher.
+func WithMetaOrderingKey(key string) MetaOrderingKeyOption {
+	return MetaOrderingKeyOption(key)
+}
+
+// MetaOrderingKeyOption is an option type for setting the metadata partition key for publishers.
+type MetaOrderingKeyOption string
+
+func (m MetaOrderingKeyOption) applyPublisher(p *Publisher) {
+	p.metaOrdKey = string(m)
+}
+
+// WithDefaultOrderingKey returns an option to configure the default partition key of the Publisher.
+func WithDefaultOrderingKey(key string) DefaultOrderingKeyOption {
+	return DefaultOrderingKeyOption(key)
+}
+
+// DefaultOrderingKeyOption is an option type for setting the default partition key for publishers.
+type DefaultOrderingKeyOption string
+
+func (d DefaultOrderingKeyOption) applyPublisher(p *Publisher) {
+	p.defaultOrdKey = string(d)
+}
+
+// WithMessageIDKey returns an option to modify the default message id header key of publishers or subscribers.
+func WithMessageIDKey(key string) MessageIDKeyOption {
+	return MessageIDKeyOption(key)
+}
+
+// MessageIDKeyOption is an option type for setting the message id header key for publishers or subscribers.
+type MessageIDKeyOption string
+
+func (m MessageIDKeyOption) applyPublisher(p *Publisher) {
+	p.msgIDKey = string(m)
+}
+
+func (m MessageIDKeyOption) applySubscriber(s *Subscriber) {
+	s.msgIDKey = string(m)
+}
+
+// WithClientOptions returns an option to add kafka client options, ex: authentication, TLS or start offset.
+// They are used by the clients created by Open and Subscriber, a client given to New is used as it is.
+func WithClientOptions(opts ...kgo.Opt) ClientOptions {
+	return ClientOptions(opts)
+}
+
+// ClientOptions is an option type for adding kafka client options for publishers or subscribers.
+type ClientOptions []kgo.Opt
+
+func (c ClientOptions) applyPublisher(p *Publisher) {
+	p.clientOpts = append(p.clientOpts, c...)
+}
+
+func (c ClientOptions) applySubscriber(s *Subscriber) {
+	s.clientOpts = append(s.clientOpts, c...)
+}
+
+// WithRedeliveryDelay returns an option to configure the time the Subscriber waits before receiving again
+// the messages of a partition after a handler fails.
+func WithRedeliveryDelay(d time.Duration) RedeliveryDelayOption {
+	return RedeliveryDelayOption(d)
+}
+
+// RedeliveryDelayOption is an option type for setting the redelivery delay for subscribers.
+type RedeliveryDelayOption time.Duration
+
+func (r RedeliveryDelayOption) applySubscriber(s *Subscriber) {
+	s.redeliveryDelay = time.Duration(r)
+}
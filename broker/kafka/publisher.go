@@ -0,0 +1,110 @@
+// Package kafka Apache Kafka broker implementation
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+var _ broker.Broker = &Publisher{}
+
+// Open returns a new Publisher instance connected to the given brokers,
+// the client is created with the options given by WithClientOptions.
+func Open(seeds []string, topic string, opts ...PublisherOption) (*Publisher, error) {
+	p := New(nil, topic, opts...)
+
+	client, err := kgo.NewClient(append([]kgo.Opt{kgo.SeedBrokers(seeds...)}, p.clientOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("creating kafka client: %w", err)
+	}
+	p.client = client
+
+	return p, nil
+}
+
+// New returns a new Publisher instance.
+func New(client *kgo.Client, topic string, opts ...PublisherOption) *Publisher {
+	p := Publisher{
+		client:   client,
+		topic:    topic,
+		msgIDKey: broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt.applyPublisher(&p)
+	}
+
+	return &p
+}
+
+// Publisher handles the kafka topic messages.
+type Publisher struct {
+	// kafka client used to produce the records
+	client *kgo.Client
+	// options used by Open to create the client
+	clientOpts []kgo.Opt
+	// topic where are going to publish messages
+	topic string
+	// meta property of the message to use as partition key
+	metaOrdKey string
+	// default partition key in case not provided in message metadata
+	defaultOrdKey string
+	// header key where will be send the message id.
+	msgIDKey string
+}
+
+// Publish publishes the given message to the kafka topic and waits until it is acknowledged.
+// Message metadata is sent as record headers.
+func (p Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	md := msg.Metadata()
+
+	headers := make([]kgo.RecordHeader, 0, len(md)+1)
+	for _, k := range slices.Sorted(maps.Keys(md)) {
+		headers = append(headers, kgo.RecordHeader{Key: k, Value: []byte(md[k])})
+	}
+	headers = append(headers, kgo.RecordHeader{Key: p.msgIDKey, Value: []byte(msg.ID())})
+
+	err := p.client.ProduceSync(ctx, &kgo.Record{
+		Topic:   p.topic,
+		Key:     p.partitionKey(msg),
+		Value:   msg.Payload(),
+		Headers: headers,
+	}).FirstErr()
+	if err != nil {
+		return fmt.Errorf("publishing message: %w", err)
+	}
+
+	return nil
+}
+
+// Close sends the pending records and closes the kafka client.
+func (p Publisher) Close(ctx context.Context) error {
+	defer p.client.Close()
+
+	if err := p.client.Flush(ctx); err != nil {
+		return fmt.Errorf("flushing pending messages: %w", err)
+	}
+
+	return nil
+}
+
+// partitionKey tries to get the partition key from message metadata
+// in case the message does not have the key it defaults to Publisher setup.
+// Without key the records are spread across partitions.
+func (p Publisher) partitionKey(msg messenger.Message) []byte {
+	if key, ok := msg.Metadata()[p.metaOrdKey]; ok {
+		return []byte(key)
+	}
+
+	if p.defaultOrdKey != "" {
+		return []byte(p.defaultOrdKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/broker/kafka"
+)
+
+// consume returns the first record of the test topic.
+func consume(ctx context.Context, t *testing.T, seeds []string) *kgo.Record {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	fetches := newClient(t, seeds, kgo.ConsumeTopics(topic)).PollRecords(ctx, 1)
+	require.NoError(t, fetches.Err0())
+
+	recs := fetches.Records()
+	require.Len(t, recs, 1)
+
+	return recs[0]
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"aggregate_id": "29a7556a-ae85-4c1d-8f04-d57ed3122586", "type": "created"},
+		MsgPayload:  []byte("some message"),
+	}
+
+	for _, tc := range []struct {
+		name            string
+		opts            []kafka.PublisherOption
+		expectedKey     []byte
+		expectedHeaders []kgo.RecordHeader
+	}{
+		{
+			name: "no ordering key",
+			expectedHeaders: []kgo.RecordHeader{
+				{Key: "aggregate_id", Value: []byte(m.MsgMetadata["aggregate_id"])},
+				{Key: "type", Value: []byte("created")},
+				{Key: broker.MessageIDKey, Value: []byte(m.MsgID)},
+			},
+		},
+		{
+			name:        "default ordering key",
+			opts:        []kafka.PublisherOption{kafka.WithDefaultOrderingKey("default-key")},
+			expectedKey: []byte("default-key"),
+			expectedHeaders: []kgo.RecordHeader{
+				{Key: "aggregate_id", Value: []byte(m.MsgMetadata["aggregate_id"])},
+				{Key: "type", Value: []byte("created")},
+				{Key: broker.MessageIDKey, Value: []byte(m.MsgID)},
+			},
+		},
+		{
+			name: "metadata ordering key",
+			opts: []kafka.PublisherOption{
+				kafka.WithDefaultOrderingKey("default-key"),
+				kafka.WithMetaOrderingKey("aggregate_id"),
+			},
+			expectedKey: []byte(m.MsgMetadata["aggregate_id"]),
+			expectedHeaders: []kgo.RecordHeader{
+				{Key: "aggregate_id", Value: []byte(m.MsgMetadata["aggregate_id"])},
+				{Key: "type", Value: []byte("created")},
+				{Key: broker.MessageIDKey, Value: []byte(m.MsgID)},
+			},
+		},
+		{
+			name: "custom message id key",
+			opts: []kafka.PublisherOption{kafka.WithMessageIDKey("custom_key")},
+			expectedHeaders: []kgo.RecordHeader{
+				{Key: "aggregate_id", Value: []byte(m.MsgMetadata["aggregate_id"])},
+				{Key: "type", Value: []byte("created")},
+				{Key: "custom_key", Value: []byte(m.MsgID)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			seeds := newCluster(t, 1)
+			p := kafka.New(newClient(t, seeds), topic, tc.opts...)
+
+			require.NoError(t, p.Publish(ctx, m))
+
+			rec := consume(ctx, t, seeds)
+			require.Equal(t, m.Payload(), rec.Value)
+			require.Equal(t, tc.expectedKey, rec.Key)
+			require.Equal(t, tc.expectedHeaders, rec.Headers)
+		})
+	}
+}
+
+func TestPublishFails(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := kafka.New(newClient(t, newCluster(t, 1)), topic)
+
+	msg, err := messenger.NewMessage([]byte("some message"))
+	require.NoError(t, err)
+	require.ErrorIs(t, p.Publish(ctx, msg), context.Canceled)
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	seeds := newCluster(t, 1)
+	p, err := kafka.Open([]string{"127.0.0.1:1"}, topic, kafka.WithClientOptions(kgo.SeedBrokers(seeds...)))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close(ctx) })
+
+	msg, err := messenger.NewMessage([]byte("some message"))
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(ctx, msg))
+
+	require.Equal(t, msg.Payload(), consume(ctx, t, seeds).Value)
+}
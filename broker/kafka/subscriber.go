@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const defaultRedeliveryDelay = time.Second
+
+// ErrDuplicateSubscription is returned by Listen when more than one subscription is registered for the same topic.
+var ErrDuplicateSubscription = errors.New("duplicate subscription")
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// NewSubscriber returns a new Subscriber instance that consumes the topics as a member of the consumer group,
+// the registered subscriptions names are the topics to consume.
+func NewSubscriber(seeds []string, group string, opts ...SubscriberOption) *Subscriber {
+	s := Subscriber{
+		seeds:           seeds,
+		group:           group,
+		errHandler:      log.NewDefault(),
+		redeliveryDelay: defaultRedeliveryDelay,
+		msgIDKey:        broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt.applySubscriber(&s)
+	}
+
+	return &s
+}
+
+// Subscriber consumes kafka topics as a consumer group and handles the records with the registered subscriptions.
+// The partitions are handled in parallel, keeping the records order within each partition.
+// Offsets are committed once the records are handled, if a handler fails the partition is consumed
+// again from the failed record, unless the error is permanent, in which case the record is skipped.
+type Subscriber struct {
+	seeds      []string
+	group      string
+	clientOpts []kgo.Opt
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	redeliveryDelay time.Duration
+	// header key where the message id is received.
+	msgIDKey string
+}
+
+// Register adds one or more subscriptions to the Subscriber, each topic can only have one subscription.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.subs = append(s.subs, subs...)
+}
+
+// Listen joins the consumer group and starts handling the records of the registered subscriptions topics.
+// It blocks until the context is cancelled, then it stops receiving records and returns after
+// the records being processed finish, handlers are not cancelled until Shutdown grace period ends.
+// It returns ErrDuplicateSubscription if a topic has more than one subscription, and the fetch error
+// if the kafka client fails with a non retriable error, ex: authentication failures or deleted topics.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	subs := make(map[string]messenger.Subscription, len(s.subs))
+	for _, sub := range s.subs {
+		if _, ok := subs[sub.Name()]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateSubscription, sub.Name())
+		}
+		subs[sub.Name()] = sub
+	}
+
+	return s.Lifecycle.Listen(ctx, func(ctx, handleCtx context.Context) error {
+		return s.consume(ctx, handleCtx, subs)
+	})
+}
+
+// consume polls the subscriptions topics as a consumer group until the context is done or fetching fails.
+func (s *Subscriber) consume(ctx, handleCtx context.Context, subs map[string]messenger.Subscription) error {
+	cl, err := kgo.NewClient(append([]kgo.Opt{
+		kgo.SeedBrokers(s.seeds...),
+		kgo.ConsumerGroup(s.group),
+		kgo.ConsumeTopics(slices.Collect(maps.Keys(subs))...),
+		kgo.DisableAutoCommit(),
+		kgo.BlockRebalanceOnPoll(),
+	}, s.clientOpts...)...)
+	if err != nil {
+		return fmt.Errorf("creating kafka client: %w", err)
+	}
+	defer cl.CloseAllowingRebalance()
+
+	for {
+		fetches := cl.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return nil
+		}
+		var fatalErr error
+		fetches.EachError(func(topic string, partition int32, err error) {
+			err = fmt.Errorf("fetching %s partition %d: %w", topic, partition, err)
+			if fatalErr == nil && !retriable(err) {
+				fatalErr = err
+				return
+			}
+			s.errHandler.Error(ctx, err)
+		})
+		if fatalErr != nil {
+			return fatalErr
+		}
+
+		if redeliver := s.handle(handleCtx, cl, subs, fetches); redeliver {
+			s.wait(ctx)
+		}
+		cl.AllowRebalance()
+	}
+}
+
+// handle processes the fetched partitions in parallel and commits the handled records,
+// it returns true if any partition has to be consumed again.
+func (s *Subscriber) handle(
+	ctx context.Context,
+	cl *kgo.Client,
+	subs map[string]messenger.Subscription,
+	fetches kgo.Fetches,
+) bool {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		handled   []*kgo.Record
+		redeliver bool
+	)
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		wg.Go(func() {
+			recs, ok := s.handlePartition(ctx, cl, subs[p.Topic], p)
+
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, recs...)
+			redeliver = redeliver || !ok
+		})
+	})
+	wg.Wait()
+
+	if len(handled) > 0 {
+		if err := cl.CommitRecords(ctx, handled...); err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("committing offsets: %w", err))
+		}
+	}
+
+	return redeliver
+}
+
+// handlePartition processes the partition records in order, returning the handled ones.
+// If a handler fails it rewinds the partition to the failed record and returns false.
+func (s *Subscriber) handlePartition(
+	ctx context.Context,
+	cl *kgo.Client,
+	sub messenger.Subscription,
+	p kgo.FetchTopicPartition,
+) ([]*kgo.Record, bool) {
+	handled := make([]*kgo.Record, 0, len(p.Records))
+	for _, r := range p.Records {
+		if err := sub.Handle(ctx, s.parseRecord(r)); err != nil {
+			s.errHandler.Error(ctx, err)
+			if !messenger.IsPermanent(err) {
+				cl.SetOffsets(map[string]map[int32]kgo.EpochOffset{
+					r.Topic: {r.Partition: {Epoch: r.LeaderEpoch, Offset: r.Offset}},
+				})
+
+				return handled, false
+			}
+		}
+		handled = append(handled, r)
+	}
+
+	return handled, true
+}
+
+// parseRecord transforms the kafka record into a messenger message, restoring the message id
+// from the headers, if it is not present the record position is used as identifier.
+func (s *Subscriber) parseRecord(r *kgo.Record) *messenger.GenericMessage {
+	msg := messenger.GenericMessage{
+		MsgPayload:  r.Value,
+		MsgMetadata: make(map[string]string, len(r.Headers)),
+	}
+	for _, h := range r.Headers {
+		if h.Key == s.msgIDKey {
+			msg.MsgID = string(h.Value)
+			continue
+		}
+		msg.MsgMetadata[h.Key] = string(h.Value)
+	}
+	if msg.MsgID == "" {
+		msg.MsgID = fmt.Sprintf("%s-%d-%d", r.Topic, r.Partition, r.Offset)
+	}
+
+	return &msg
+}
+
+// retriable reports whether the client recovers from the fetch error by itself.
+// Kafka errors flagged as non retriable, ex: authorization failures, and unknown topics,
+// the partitions of a deleted topic, are not.
+func retriable(err error) bool {
+	var kErr *kerr.Error
+	if !errors.As(err, &kErr) {
+		return true
+	}
+
+	return kErr.Retriable && !errors.Is(err, kerr.UnknownTopicOrPartition)
+}
+
+// wait blocks the redelivery delay or until the context is done.
+func (s *Subscriber) wait(ctx context.Context) {
+	t := time.NewTimer(s.redeliveryDelay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,191 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/kafka"
+)
+
+const group = "test-group"
+
+// publish sends the given amount of messages to the test topic, all of them with the same partition key.
+func publish(ctx context.Context, t *testing.T, seeds []string, n int) []messenger.Message {
+	t.Helper()
+
+	p := kafka.New(newClient(t, seeds), topic, kafka.WithDefaultOrderingKey("key"))
+
+	msgs := make([]messenger.Message, 0, n)
+	for i := range n {
+		msg, err := messenger.NewMessage([]byte(fmt.Sprintf("message %d", i)))
+		require.NoError(t, err)
+		msg.SetMetadata("index", fmt.Sprint(i))
+		require.NoError(t, p.Publish(ctx, msg))
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// nextOffset returns the offset of the first record received by a new member of the group,
+// as it starts consuming after the committed records.
+func nextOffset(ctx context.Context, t *testing.T, seeds []string) int64 {
+	t.Helper()
+
+	fetches := newClient(t, seeds, kgo.ConsumerGroup(group), kgo.ConsumeTopics(topic)).PollRecords(ctx, 1)
+	recs := fetches.Records()
+	if len(recs) == 0 {
+		return -1
+	}
+
+	return recs[0].Offset
+}
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handles and commits messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		seeds := newCluster(t, 1)
+		msgs := publish(ctx, t, seeds, 3)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []messenger.Message
+		s := kafka.NewSubscriber(seeds, group)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg)
+			if len(received) == len(msgs) {
+				stop()
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+
+		require.Len(t, received, len(msgs))
+		for i, msg := range msgs {
+			require.Equal(t, msg.ID(), received[i].ID())
+			require.Equal(t, msg.Payload(), received[i].Payload())
+			require.Equal(t, msg.Metadata(), received[i].Metadata())
+		}
+
+		publish(ctx, t, seeds, 1)
+		require.Equal(t, int64(len(msgs)), nextOffset(ctx, t, seeds))
+	})
+
+	t.Run("redelivers failed messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		seeds := newCluster(t, 1)
+		msgs := publish(ctx, t, seeds, 2)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var (
+			mu       sync.Mutex
+			received []string
+		)
+		failed := false
+		s := kafka.NewSubscriber(seeds, group, kafka.WithRedeliveryDelay(time.Millisecond))
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, msg.ID())
+			if msg.ID() == msgs[1].ID() && !failed {
+				failed = true
+				return errors.New("handler error")
+			}
+			if len(received) == 3 {
+				stop()
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID(), msgs[1].ID(), msgs[1].ID()}, received)
+	})
+
+	t.Run("skips messages failing with permanent error", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		seeds := newCluster(t, 1)
+		msgs := publish(ctx, t, seeds, 2)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []string
+		s := kafka.NewSubscriber(seeds, group)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg.ID())
+			if msg.ID() == msgs[0].ID() {
+				return messenger.Permanent(errors.New("invalid message"))
+			}
+			stop()
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID(), msgs[1].ID()}, received)
+	})
+
+	t.Run("rejects duplicated subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		s := kafka.NewSubscriber(newCluster(t, 1), group)
+		s.Register(
+			messenger.NewSubscription(topic, func(context.Context, messenger.Message) error { return nil }),
+			messenger.NewSubscription(topic, func(context.Context, messenger.Message) error { return nil }),
+		)
+
+		require.ErrorIs(t, s.Listen(context.Background()), kafka.ErrDuplicateSubscription)
+	})
+
+	t.Run("returns non retriable fetch errors", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		c := newFakeCluster(t, 1)
+		c.ControlKey(int16(kmsg.Fetch), func(r kmsg.Request) (kmsg.Response, error, bool) {
+			c.KeepControl()
+			req, _ := r.(*kmsg.FetchRequest)
+			resp, _ := req.ResponseKind().(*kmsg.FetchResponse)
+			for _, rt := range req.Topics {
+				st := kmsg.NewFetchResponseTopic()
+				st.Topic, st.TopicID = rt.Topic, rt.TopicID
+				for _, rp := range rt.Partitions {
+					sp := kmsg.NewFetchResponseTopicPartition()
+					sp.Partition = rp.Partition
+					sp.ErrorCode = kerr.TopicAuthorizationFailed.Code
+					st.Partitions = append(st.Partitions, sp)
+				}
+				resp.Topics = append(resp.Topics, st)
+			}
+
+			return resp, nil, true
+		})
+		publish(ctx, t, c.ListenAddrs(), 1)
+
+		s := kafka.NewSubscriber(c.ListenAddrs(), group)
+		s.Register(messenger.NewSubscription(topic, func(context.Context, messenger.Message) error { return nil }))
+
+		require.ErrorIs(t, s.Listen(ctx), kerr.TopicAuthorizationFailed)
+	})
+}
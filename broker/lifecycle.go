@@ -0,0 +1,62 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+// Lifecycle tracks the listening process of a subscriber, so it can be stopped gracefully from another goroutine.
+// Subscribers embed it to provide Shutdown and Close, and run their listening process with Lifecycle.Listen.
+// The zero value is ready to use and it can listen again once stopped.
+type Lifecycle struct {
+	mu            sync.Mutex
+	stopReceiving context.CancelFunc
+	stopHandling  context.CancelFunc
+	done          chan struct{}
+}
+
+// Listen runs the listening process and returns its result. The process receives messages with ctx,
+// cancelled once the given context is done or on Shutdown, and handles them with handleCtx, which is only
+// cancelled once the Shutdown grace period ends so the messages being processed can finish.
+func (l *Lifecycle) Listen(ctx context.Context, listen func(ctx, handleCtx context.Context) error) error {
+	handleCtx, stopHandling := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopHandling()
+
+	ctx, stopReceiving := context.WithCancel(ctx)
+	defer stopReceiving()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	l.mu.Lock()
+	l.stopReceiving, l.stopHandling, l.done = stopReceiving, stopHandling, done
+	l.mu.Unlock()
+
+	return listen(ctx, handleCtx)
+}
+
+// Shutdown stops receiving new messages and waits until the messages being processed finish.
+// If the given context is done before, it cancels the handlers context and returns the context error.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	stopReceiving, stopHandling, done := l.stopReceiving, l.stopHandling, l.done
+	l.mu.Unlock()
+	if stopReceiving == nil {
+		return nil
+	}
+	stopReceiving()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		stopHandling()
+
+		return ctx.Err()
+	}
+}
+
+// Close gracefully stops the subscriber, see Shutdown.
+func (l *Lifecycle) Close(ctx context.Context) error {
+	return l.Shutdown(ctx)
+}
@@ -0,0 +1,119 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger/broker"
+)
+
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shutdown before listening", func(t *testing.T) {
+		t.Parallel()
+
+		var l broker.Lifecycle
+		require.NoError(t, l.Shutdown(context.Background()))
+	})
+
+	t.Run("returns the listening process result", func(t *testing.T) {
+		t.Parallel()
+
+		listenErr := errors.New("listen error")
+		var l broker.Lifecycle
+		require.ErrorIs(t, l.Listen(context.Background(), func(context.Context, context.Context) error {
+			return listenErr
+		}), listenErr)
+	})
+
+	t.Run("cancelling the context stops receiving but not handling", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var l broker.Lifecycle
+		require.NoError(t, l.Listen(ctx, func(ctx, handleCtx context.Context) error {
+			<-ctx.Done()
+
+			return handleCtx.Err()
+		}))
+	})
+
+	t.Run("shutdown waits the messages being processed", func(t *testing.T) {
+		t.Parallel()
+
+		var l broker.Lifecycle
+		started, handled := make(chan struct{}), make(chan struct{})
+		var handleErr error
+		go func() {
+			_ = l.Listen(context.Background(), func(ctx, handleCtx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				time.Sleep(50 * time.Millisecond)
+				handleErr = handleCtx.Err()
+				close(handled)
+
+				return nil
+			})
+		}()
+		<-started
+
+		require.NoError(t, l.Shutdown(context.Background()))
+		select {
+		case <-handled:
+		default:
+			t.Fatal("shutdown returned before the messages being processed finished")
+		}
+		require.NoError(t, handleErr)
+	})
+
+	t.Run("shutdown grace period ends cancels handlers", func(t *testing.T) {
+		t.Parallel()
+
+		var l broker.Lifecycle
+		started, cancelled := make(chan struct{}), make(chan struct{})
+		go func() {
+			_ = l.Listen(context.Background(), func(_, handleCtx context.Context) error {
+				close(started)
+				<-handleCtx.Done()
+				close(cancelled)
+
+				return nil
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		require.ErrorIs(t, l.Shutdown(ctx), context.DeadlineExceeded)
+		<-cancelled
+	})
+
+	t.Run("listens again after stopping", func(t *testing.T) {
+		t.Parallel()
+
+		var l broker.Lifecycle
+		for range 2 {
+			started := make(chan struct{})
+			stopped := make(chan error)
+			go func() {
+				stopped <- l.Listen(context.Background(), func(ctx, _ context.Context) error {
+					close(started)
+					<-ctx.Done()
+
+					return nil
+				})
+			}()
+			<-started
+
+			require.NoError(t, l.Close(context.Background()))
+			require.NoError(t, <-stopped)
+		}
+	})
+}
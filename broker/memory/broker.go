@@ -0,0 +1,189 @@
+// Package memory in-process broker implementation, useful for local development and tests.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+// ErrMissingTopic is returned when the message topic cannot be resolved.
+var ErrMissingTopic = errors.New("missing message topic")
+
+var _ broker.Broker = &Broker{}
+
+// BrokerOption is a function to set options to Broker.
+type BrokerOption func(*Broker)
+
+// WithMetaTopicKey setups the metadata key to get the message topic,
+// if the message does not have it the Broker topic is used.
+func WithMetaTopicKey(key string) BrokerOption {
+	return func(b *Broker) {
+		b.metaTopicKey = key
+	}
+}
+
+// New returns a new Broker instance, the topic is used when not provided in the message metadata.
+func New(topic string, opts ...BrokerOption) *Broker {
+	b := Broker{
+		topic:  topic,
+		queues: make(map[string][]*queue),
+	}
+
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	return &b
+}
+
+// Broker dispatches the published messages to the subscriptions registered to its topic within the same process.
+// Every subscription receives a copy of the message, messages published to a topic without subscriptions
+// are discarded, as nothing is persisted.
+type Broker struct {
+	// default topic in case not provided in message metadata
+	topic string
+	// meta property of the message to use as topic
+	metaTopicKey string
+
+	mu     sync.RWMutex
+	queues map[string][]*queue
+}
+
+// queue buffers the messages of a topic for a subscription.
+type queue struct {
+	msgs chan messenger.Message
+
+	mu sync.Mutex
+	// closed once the subscription stops listening, so publishers do not block on a full queue.
+	stopped chan struct{}
+	// failed messages that were waiting to be handled again when the subscription stopped listening.
+	pending []messenger.Message
+}
+
+// newQueue returns a queue waiting for its subscription to listen, publishers wait for room once it is full.
+func newQueue(size int) *queue {
+	return &queue{
+		msgs:    make(chan messenger.Message, size),
+		stopped: make(chan struct{}),
+	}
+}
+
+// listen marks the subscription as listening, once the queue is full publishers wait for room.
+func (q *queue) listen() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.stopped:
+		q.stopped = make(chan struct{})
+	default:
+	}
+}
+
+// stop marks the subscription as not listening, releasing the publishers waiting for room.
+func (q *queue) stop() {
+	q.mu.Lock()
+	close(q.stopped)
+	q.mu.Unlock()
+}
+
+// requeue keeps the message to be handled before the queued ones once the subscription listens again.
+func (q *queue) requeue(msg messenger.Message) {
+	q.mu.Lock()
+	q.pending = append(q.pending, msg)
+	q.mu.Unlock()
+}
+
+// requeued returns the oldest requeued message, if any.
+func (q *queue) requeued() (messenger.Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil, false
+	}
+	msg := q.pending[0]
+	q.pending = q.pending[1:]
+
+	return msg, true
+}
+
+func (q *queue) done() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.stopped
+}
+
+// Publish sends the message to every subscription registered to its topic, it blocks until the message is
+// queued for all of them or the context is done. Subscriptions that stopped listening with a full queue discard it.
+func (b *Broker) Publish(ctx context.Context, msg messenger.Message) error {
+	topic, err := b.messageTopic(msg)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	queues := slices.Clone(b.queues[topic])
+	b.mu.RUnlock()
+
+	for _, q := range queues {
+		m := clone(msg)
+		select {
+		case q.msgs <- m:
+			continue
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.done():
+		case q.msgs <- m:
+		}
+	}
+
+	return nil
+}
+
+// subscribe adds a queue to receive the messages of the topic.
+func (b *Broker) subscribe(topic string, size int) *queue {
+	q := newQueue(size)
+
+	b.mu.Lock()
+	b.queues[topic] = append(b.queues[topic], q)
+	b.mu.Unlock()
+
+	return q
+}
+
+// messageTopic tries to get the topic from message metadata
+// in case the message does not have the key it defaults to Broker setup.
+func (b *Broker) messageTopic(msg messenger.Message) (string, error) {
+	if topic, ok := msg.Metadata()[b.metaTopicKey]; ok && topic != "" {
+		return topic, nil
+	}
+
+	if b.topic == "" {
+		return "", ErrMissingTopic
+	}
+
+	return b.topic, nil
+}
+
+// clone copies the message, so subscriptions do not share it with the publisher nor between them.
+func clone(msg messenger.Message) *messenger.GenericMessage {
+	return &messenger.GenericMessage{
+		MsgID:       msg.ID(),
+		MsgMetadata: maps.Clone(msg.Metadata()),
+		MsgPayload:  bytes.Clone(msg.Payload()),
+		MsgAt:       msg.At(),
+	}
+}
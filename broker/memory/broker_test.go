@@ -0,0 +1,128 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/memory"
+)
+
+const (
+	topic    = "orders"
+	topicKey = "topic"
+)
+
+func newMessage(t *testing.T, payload string) *messenger.GenericMessage {
+	t.Helper()
+
+	msg, err := messenger.NewMessage([]byte(payload))
+	require.NoError(t, err)
+
+	return msg
+}
+
+// listen starts the subscriber and returns a function to stop it and wait until it finishes.
+func listen(t *testing.T, s *memory.Subscriber) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.Listen(ctx) }()
+
+	return func() {
+		cancel()
+		require.NoError(t, <-errc)
+	}
+}
+
+// receive returns a subscription named as the topic that sends the handled messages to the returned channel.
+func receive(name string) (messenger.Subscription, chan messenger.Message) {
+	msgs := make(chan messenger.Message, 100)
+
+	return messenger.NewSubscription(name, func(_ context.Context, msg messenger.Message) error {
+		msgs <- msg
+
+		return nil
+	}), msgs
+}
+
+func next(t *testing.T, msgs chan messenger.Message) messenger.Message {
+	t.Helper()
+
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message not received")
+
+		return nil
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("routes messages by topic", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		b := memory.New(topic, memory.WithMetaTopicKey(topicKey))
+		s := memory.NewSubscriber(b)
+		orders, ordersMsgs := receive(topic)
+		users, usersMsgs := receive("users")
+		s.Register(orders, users)
+		defer listen(t, s)()
+
+		order := newMessage(t, "order")
+		user := newMessage(t, "user").SetMetadata(topicKey, "users")
+		require.NoError(t, b.Publish(ctx, order))
+		require.NoError(t, b.Publish(ctx, user))
+
+		require.Equal(t, order.ID(), next(t, ordersMsgs).ID())
+		require.Equal(t, user.ID(), next(t, usersMsgs).ID())
+	})
+
+	t.Run("missing topic", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New("", memory.WithMetaTopicKey(topicKey))
+
+		require.ErrorIs(t, b.Publish(context.Background(), newMessage(t, "order")), memory.ErrMissingTopic)
+	})
+
+	t.Run("discards messages without subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		require.NoError(t, memory.New(topic).Publish(context.Background(), newMessage(t, "order")))
+	})
+
+	t.Run("blocks until the message is queued", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		memory.NewSubscriber(b, memory.WithBufferSize(1)).Register(messenger.NewSubscription(topic, nil))
+
+		require.NoError(t, b.Publish(context.Background(), newMessage(t, "first")))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, b.Publish(ctx, newMessage(t, "second")), context.DeadlineExceeded)
+	})
+
+	t.Run("does not block once subscriber stops", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithBufferSize(0))
+		sub, _ := receive(topic)
+		s.Register(sub)
+		listen(t, s)()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, b.Publish(ctx, newMessage(t, "order")))
+	})
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const (
+	defaultBufferSize      = 100
+	defaultConcurrency     = 1
+	defaultRedeliveryDelay = 100 * time.Millisecond
+)
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// SubscriberOption is a function to set options to Subscriber.
+type SubscriberOption func(*Subscriber)
+
+// WithBufferSize setups the max number of messages queued for every subscription,
+// once it is full publishing blocks until the subscription handles the queued messages
+// or the Subscriber stops listening, then the message is discarded.
+func WithBufferSize(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.bufferSize = n
+	}
+}
+
+// WithConcurrency setups the number of messages of every subscription processed at the same time,
+// with more than one the messages are not processed in the order they were published.
+func WithConcurrency(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.concurrency = n
+	}
+}
+
+// WithRedeliveryDelay setups the time the Subscriber waits before handling again a failed message.
+func WithRedeliveryDelay(d time.Duration) SubscriberOption {
+	return func(s *Subscriber) {
+		s.redeliveryDelay = d
+	}
+}
+
+// NewSubscriber returns a new Subscriber instance that receives the messages published to the Broker,
+// the registered subscriptions names are the topics to receive.
+func NewSubscriber(b *Broker, opts ...SubscriberOption) *Subscriber {
+	s := Subscriber{
+		broker:          b,
+		subs:            make([]messenger.Subscription, 0),
+		errHandler:      log.NewDefault(),
+		bufferSize:      defaultBufferSize,
+		concurrency:     defaultConcurrency,
+		redeliveryDelay: defaultRedeliveryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Subscriber handles the messages published to the Broker topics with the registered subscriptions.
+// Subscriptions with the same topic, in the same or different subscribers, receive all the messages
+// published since they are registered, queued until the Subscriber listens, so publishing blocks once
+// the buffer of a subscription that has never listened is full. A failed message is handled again after
+// the redelivery delay, keeping the messages order, unless the error is permanent, in which case the message
+// is discarded. Messages not handled once the Subscriber stops listening, including the failed ones waiting
+// to be handled again, remain queued until it listens again, meanwhile the messages exceeding the buffer size
+// are discarded.
+type Subscriber struct {
+	broker *Broker
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+	// queues of the registered subscriptions, in the same order.
+	queues []*queue
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	// guards the subscriptions and the current listening process.
+	mu sync.Mutex
+	// receiving and handling contexts of the current listening process, nil when not listening,
+	// and the workers handling the queues.
+	listenCtx context.Context
+	handleCtx context.Context
+	workers   sync.WaitGroup
+
+	bufferSize      int
+	concurrency     int
+	redeliveryDelay time.Duration
+}
+
+// Register adds one or more subscriptions to the Subscriber, they start receiving the messages
+// published to their topics, and handling them if the Subscriber is listening.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range subs {
+		q := s.broker.subscribe(sub.Name(), s.bufferSize)
+		s.subs = append(s.subs, sub)
+		s.queues = append(s.queues, q)
+		if s.listenCtx != nil {
+			s.start(sub, q)
+		}
+	}
+}
+
+// Listen starts receiving messages for all registered subscriptions.
+// It blocks until the context is cancelled, then it stops receiving messages and returns after
+// the messages being processed finish, handlers are not cancelled until Shutdown grace period ends.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen handles the queues of the subscriptions, including the ones registered meanwhile, until the context is done.
+func (s *Subscriber) listen(ctx, handleCtx context.Context) error {
+	s.mu.Lock()
+	s.listenCtx, s.handleCtx = ctx, handleCtx
+	for i, sub := range s.subs {
+		s.start(sub, s.queues[i])
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	s.listenCtx, s.handleCtx = nil, nil
+	for _, q := range s.queues {
+		q.stop()
+	}
+	s.mu.Unlock()
+	s.workers.Wait()
+
+	return nil
+}
+
+// start marks the queue as listening and starts the workers handling its messages with the subscription
+// until the Subscriber stops receiving messages, it must be called holding the lock while listening.
+func (s *Subscriber) start(sub messenger.Subscription, q *queue) {
+	q.listen()
+
+	ctx, handleCtx := s.listenCtx, s.handleCtx
+	for range max(s.concurrency, 1) {
+		s.workers.Go(func() {
+			for ctx.Err() == nil {
+				msg, ok := q.requeued()
+				if !ok {
+					select {
+					case <-ctx.Done():
+						return
+					case msg = <-q.msgs:
+					}
+				}
+				s.handle(ctx, handleCtx, sub, q, msg)
+			}
+		})
+	}
+}
+
+// handle processes the message with the subscription until it succeeds or it fails with a permanent error.
+// If the Subscriber stops receiving messages meanwhile, the message is requeued to be handled first once it listens again.
+func (s *Subscriber) handle(
+	ctx, handleCtx context.Context,
+	sub messenger.Subscription,
+	q *queue,
+	msg messenger.Message,
+) {
+	for {
+		err := sub.Handle(handleCtx, msg)
+		if err == nil {
+			return
+		}
+		s.errHandler.Error(handleCtx, err)
+		if messenger.IsPermanent(err) {
+			return
+		}
+
+		t := time.NewTimer(s.redeliveryDelay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			q.requeue(msg)
+
+			return
+		case <-t.C:
+		}
+	}
+}
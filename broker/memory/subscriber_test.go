@@ -0,0 +1,281 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/memory"
+)
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fans out messages", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		first, second := memory.NewSubscriber(b), memory.NewSubscriber(b)
+		sub1, msgs1 := receive(topic)
+		sub2, msgs2 := receive(topic)
+		sub3, msgs3 := receive(topic)
+		first.Register(sub1, sub2)
+		second.Register(sub3)
+		defer listen(t, first)()
+		defer listen(t, second)()
+
+		msg := newMessage(t, "order").SetMetadata("key", "value")
+		require.NoError(t, b.Publish(context.Background(), msg))
+
+		for _, msgs := range []chan messenger.Message{msgs1, msgs2, msgs3} {
+			received := next(t, msgs)
+			require.Equal(t, msg.ID(), received.ID())
+			require.Equal(t, msg.Payload(), received.Payload())
+			require.Equal(t, msg.Metadata(), received.Metadata())
+			require.Equal(t, msg.At(), received.At())
+		}
+	})
+
+	t.Run("receives messages published before listening", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b)
+		sub, msgs := receive(topic)
+		s.Register(sub)
+
+		msg := newMessage(t, "order")
+		require.NoError(t, b.Publish(context.Background(), msg))
+		defer listen(t, s)()
+
+		require.Equal(t, msg.ID(), next(t, msgs).ID())
+	})
+
+	t.Run("keeps messages order", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithBufferSize(5))
+		sub, msgs := receive(topic)
+		s.Register(sub)
+		defer listen(t, s)()
+
+		published := make([]string, 0, 50)
+		for i := range 50 {
+			msg := newMessage(t, fmt.Sprint(i))
+			require.NoError(t, b.Publish(context.Background(), msg))
+			published = append(published, msg.ID())
+		}
+
+		received := make([]string, 0, len(published))
+		for range published {
+			received = append(received, next(t, msgs).ID())
+		}
+		require.Equal(t, published, received)
+	})
+
+	t.Run("isolates messages between subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b)
+		mutate := messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			msg.Metadata()["key"] = "modified"
+
+			return nil
+		})
+		sub, msgs := receive(topic)
+		s.Register(mutate, sub)
+		defer listen(t, s)()
+
+		msg := newMessage(t, "order").SetMetadata("key", "value")
+		require.NoError(t, b.Publish(context.Background(), msg))
+
+		require.Equal(t, "value", next(t, msgs).Metadata()["key"])
+		require.Equal(t, "value", msg.Metadata()["key"])
+	})
+
+	t.Run("redelivers failed messages", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithRedeliveryDelay(time.Millisecond))
+		var attempts atomic.Int32
+		handled := make(chan messenger.Message, 1)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("handler error")
+			}
+			handled <- msg
+
+			return nil
+		}))
+		defer listen(t, s)()
+
+		msg := newMessage(t, "order")
+		require.NoError(t, b.Publish(context.Background(), msg))
+
+		require.Equal(t, msg.ID(), next(t, handled).ID())
+		require.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("requeues failed messages waiting redelivery once it stops listening", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithRedeliveryDelay(time.Hour))
+		var attempts atomic.Int32
+		handled := make(chan messenger.Message, 2)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			handled <- msg
+			if attempts.Add(1) == 1 {
+				return errors.New("handler error")
+			}
+
+			return nil
+		}))
+
+		failed, queued := newMessage(t, "failed"), newMessage(t, "queued")
+		require.NoError(t, b.Publish(context.Background(), failed))
+		stop := listen(t, s)
+		require.Equal(t, failed.ID(), next(t, handled).ID())
+		stop()
+
+		require.NoError(t, b.Publish(context.Background(), queued))
+		defer listen(t, s)()
+
+		require.Equal(t, failed.ID(), next(t, handled).ID())
+		require.Equal(t, queued.ID(), next(t, handled).ID())
+	})
+
+	t.Run("discards messages failing with permanent error", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithRedeliveryDelay(time.Millisecond))
+		invalid := newMessage(t, "invalid")
+		handled := make(chan messenger.Message, 2)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			handled <- msg
+			if msg.ID() == invalid.ID() {
+				return messenger.Permanent(errors.New("invalid message"))
+			}
+
+			return nil
+		}))
+		defer listen(t, s)()
+
+		valid := newMessage(t, "valid")
+		require.NoError(t, b.Publish(context.Background(), invalid))
+		require.NoError(t, b.Publish(context.Background(), valid))
+
+		require.Equal(t, invalid.ID(), next(t, handled).ID())
+		require.Equal(t, valid.ID(), next(t, handled).ID())
+	})
+
+	t.Run("handles messages concurrently", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b, memory.WithConcurrency(3))
+		var running, maxRunning atomic.Int32
+		handled := make(chan messenger.Message, 6)
+		s.Register(messenger.NewSubscription(topic, func(_ context.Context, msg messenger.Message) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			handled <- msg
+
+			return nil
+		}))
+		defer listen(t, s)()
+
+		for i := range 6 {
+			require.NoError(t, b.Publish(context.Background(), newMessage(t, fmt.Sprint(i))))
+		}
+		for range 6 {
+			next(t, handled)
+		}
+		require.Equal(t, int32(3), maxRunning.Load())
+	})
+
+	t.Run("handles the messages queued while not listening", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b)
+		sub, msgs := receive(topic)
+		s.Register(sub)
+
+		stop := listen(t, s)
+		msg := newMessage(t, "order")
+		require.NoError(t, b.Publish(context.Background(), msg))
+		require.Equal(t, msg.ID(), next(t, msgs).ID())
+		stop()
+
+		msg = newMessage(t, "invoice")
+		require.NoError(t, b.Publish(context.Background(), msg))
+		defer listen(t, s)()
+		require.Equal(t, msg.ID(), next(t, msgs).ID())
+	})
+
+	t.Run("handles subscriptions registered while listening", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic, memory.WithMetaTopicKey(topicKey))
+		s := memory.NewSubscriber(b, memory.WithBufferSize(0))
+		first, firstMsgs := receive(topic)
+		s.Register(first)
+		defer listen(t, s)()
+
+		msg := newMessage(t, "order")
+		require.NoError(t, b.Publish(context.Background(), msg))
+		require.Equal(t, msg.ID(), next(t, firstMsgs).ID())
+
+		second, secondMsgs := receive("invoices")
+		s.Register(second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		msg = newMessage(t, "invoice").SetMetadata(topicKey, "invoices")
+		require.NoError(t, b.Publish(ctx, msg))
+		require.Equal(t, msg.ID(), next(t, secondMsgs).ID())
+	})
+
+	t.Run("shutdown waits handlers", func(t *testing.T) {
+		t.Parallel()
+
+		b := memory.New(topic)
+		s := memory.NewSubscriber(b)
+		started := make(chan struct{})
+		var finished atomic.Bool
+		s.Register(messenger.NewSubscription(topic, func(ctx context.Context, _ messenger.Message) error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(ctx.Err() == nil)
+
+			return nil
+		}))
+
+		errc := make(chan error, 1)
+		go func() { errc <- s.Listen(context.Background()) }()
+
+		require.NoError(t, b.Publish(context.Background(), newMessage(t, "order")))
+		<-started
+
+		require.NoError(t, s.Close(context.Background()))
+		require.True(t, finished.Load())
+		require.NoError(t, <-errc)
+	})
+}
@@ -0,0 +1,51 @@
+package nats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	stream  = "EVENTS"
+	subject = "events.created"
+)
+
+// newJetStream starts an embedded nats server with JetStream enabled and the test stream,
+// and returns a JetStream instance connected to it.
+func newJetStream(ctx context.Context, t *testing.T) jetstream.JetStream {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{"events.>"},
+	})
+	require.NoError(t, err)
+
+	return js
+}
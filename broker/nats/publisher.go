@@ -0,0 +1,153 @@
+// Package nats NATS JetStream broker implementation
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+// ErrMissingSubject is returned when the message subject cannot be resolved.
+var ErrMissingSubject = errors.New("missing message subject")
+
+// ErrReservedHeader is returned when a metadata key starts with the Nats- prefix reserved by nats headers.
+var ErrReservedHeader = errors.New("metadata key is a reserved header")
+
+var _ broker.Broker = &Publisher{}
+
+// Option is a function to set options to Publisher or Subscriber.
+type Option func(any)
+
+// WithMetaSubjectKey setups the metadata key to get the message subject,
+// if the message does not have it the Publisher subject is used.
+func WithMetaSubjectKey(key string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.metaSubjectKey = key
+	}
+}
+
+// WithMessageIDKey modify default message id header key, used by Publisher and Subscriber.
+func WithMessageIDKey(key string) Option {
+	return func(c any) {
+		switch v := c.(type) {
+		case *Publisher:
+			v.msgIDKey = key
+		case *Subscriber:
+			v.msgIDKey = key
+		}
+	}
+}
+
+// Open returns a new Publisher instance connected to the given nats server url.
+func Open(url, subject string, opts ...Option) (*Publisher, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("creating jetstream instance: %w", err)
+	}
+
+	p := New(js, subject, opts...)
+	p.conn = conn
+
+	return p, nil
+}
+
+// New returns a new Publisher instance, the subject is used when not provided in the message metadata.
+func New(js jetstream.JetStream, subject string, opts ...Option) *Publisher {
+	p := Publisher{
+		js:       js,
+		subject:  subject,
+		msgIDKey: broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return &p
+}
+
+// Publisher publishes messages to JetStream subjects. The message id is sent as Nats-Msg-Id header,
+// so the server discards the messages already published within the stream duplicates window.
+type Publisher struct {
+	// jetstream instance where are going to publish messages
+	js jetstream.JetStream
+	// connection opened by the publisher, nil if provided by the caller.
+	conn *nats.Conn
+	// default subject in case not provided in message metadata
+	subject string
+	// meta property of the message to use as subject
+	metaSubjectKey string
+	// header key where will be send the message id.
+	msgIDKey string
+}
+
+// Publish publishes the given message to its subject and waits the stream acknowledgement.
+// Message metadata is sent as message headers,
+// it returns ErrReservedHeader if a metadata key starts with the Nats- prefix.
+func (p Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	subject, err := p.messageSubject(msg)
+	if err != nil {
+		return err
+	}
+
+	md := msg.Metadata()
+	header := make(nats.Header, len(md)+1)
+	for k, v := range md {
+		if strings.HasPrefix(k, reservedHeaderPrefix) {
+			return fmt.Errorf("%w: %s", ErrReservedHeader, k)
+		}
+		header.Set(k, v)
+	}
+	header.Set(p.msgIDKey, msg.ID())
+
+	_, err = p.js.PublishMsg(ctx, &nats.Msg{
+		Subject: subject,
+		Data:    msg.Payload(),
+		Header:  header,
+	}, jetstream.WithMsgID(msg.ID()))
+	if err != nil {
+		return fmt.Errorf("publishing message: %w", err)
+	}
+
+	return nil
+}
+
+// Close drains the connection if it was opened by the Publisher.
+func (p Publisher) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+
+	return p.conn.Drain()
+}
+
+// messageSubject tries to get the subject from message metadata
+// in case the message does not have the key it defaults to Publisher setup.
+func (p Publisher) messageSubject(msg messenger.Message) (string, error) {
+	if subject, ok := msg.Metadata()[p.metaSubjectKey]; ok && subject != "" {
+		return subject, nil
+	}
+
+	if p.subject == "" {
+		return "", ErrMissingSubject
+	}
+
+	return p.subject, nil
+}
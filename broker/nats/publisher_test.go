@@ -0,0 +1,117 @@
+package nats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	natsx "github.com/x4b1/messenger/broker/nats"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"aggregate_id": "29a7556a-ae85-4c1d-8f04-d57ed3122586", "subject": "events.updated"},
+		MsgPayload:  []byte("some message"),
+	}
+
+	for _, tc := range []struct {
+		name            string
+		subject         string
+		opts            []natsx.Option
+		expectedSubject string
+		expectedIDKey   string
+	}{
+		{
+			name:            "default subject",
+			subject:         subject,
+			expectedSubject: subject,
+			expectedIDKey:   broker.MessageIDKey,
+		},
+		{
+			name:            "metadata subject",
+			subject:         subject,
+			opts:            []natsx.Option{natsx.WithMetaSubjectKey("subject")},
+			expectedSubject: "events.updated",
+			expectedIDKey:   broker.MessageIDKey,
+		},
+		{
+			name:            "custom message id key",
+			subject:         subject,
+			opts:            []natsx.Option{natsx.WithMessageIDKey("custom_key")},
+			expectedSubject: subject,
+			expectedIDKey:   "custom_key",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			js := newJetStream(ctx, t)
+
+			require.NoError(t, natsx.New(js, tc.subject, tc.opts...).Publish(ctx, m))
+
+			s, err := js.Stream(ctx, stream)
+			require.NoError(t, err)
+			raw, err := s.GetMsg(ctx, 1)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedSubject, raw.Subject)
+			require.Equal(t, m.Payload(), raw.Data)
+			require.Equal(t, m.MsgID, raw.Header.Get(jetstream.MsgIDHeader))
+			require.Equal(t, m.MsgID, raw.Header.Get(tc.expectedIDKey))
+			require.Equal(t, m.MsgMetadata["aggregate_id"], raw.Header.Get("aggregate_id"))
+		})
+	}
+
+	t.Run("deduplicates messages", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		js := newJetStream(ctx, t)
+		p := natsx.New(js, subject)
+
+		require.NoError(t, p.Publish(ctx, m))
+		require.NoError(t, p.Publish(ctx, m))
+
+		s, err := js.Stream(ctx, stream)
+		require.NoError(t, err)
+		info, err := s.Info(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), info.State.Msgs)
+	})
+
+	t.Run("fails without subject", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		p := natsx.New(newJetStream(ctx, t), "", natsx.WithMetaSubjectKey("subject"))
+
+		msg, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		require.ErrorIs(t, p.Publish(ctx, msg), natsx.ErrMissingSubject)
+	})
+	t.Run("fails with reserved header", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		js := newJetStream(ctx, t)
+
+		msg, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		msg.SetMetadata("Nats-Expected-Stream", "OTHER")
+		require.ErrorIs(t, natsx.New(js, subject).Publish(ctx, msg), natsx.ErrReservedHeader)
+
+		s, err := js.Stream(ctx, stream)
+		require.NoError(t, err)
+		info, err := s.Info(ctx)
+		require.NoError(t, err)
+		require.Zero(t, info.State.Msgs)
+	})
+}
@@ -0,0 +1,259 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const (
+	defaultConcurrency = 1
+	// reservedHeaderPrefix is the prefix of the headers set by nats, they are not part of the message metadata.
+	reservedHeaderPrefix = "Nats-"
+)
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// WithNakDelay setups the time the server waits before redelivering a failed message.
+func WithNakDelay(d time.Duration) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.nakDelay = d
+	}
+}
+
+// WithConcurrency setups the number of messages of every subscription processed at the same time,
+// with more than one the messages are not processed in order.
+func WithConcurrency(n int) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.concurrency = n
+	}
+}
+
+// WithMaxMessages setups the max number of messages buffered by every subscription.
+func WithMaxMessages(n int) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.maxMessages = n
+	}
+}
+
+// NewSubscriber returns a new Subscriber instance for the given stream,
+// the registered subscriptions names are the stream durable consumers names.
+func NewSubscriber(js jetstream.JetStream, stream string, opts ...Option) *Subscriber {
+	s := Subscriber{
+		js:          js,
+		stream:      stream,
+		subs:        make([]messenger.Subscription, 0),
+		errHandler:  log.NewDefault(),
+		concurrency: defaultConcurrency,
+		msgIDKey:    broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Subscriber consumes messages from JetStream durable consumers and handles them with the registered subscriptions.
+// Messages are acknowledged once handled, negatively acknowledged with the configured delay if the handler fails,
+// and terminated if the handler fails with a permanent error, so they are not redelivered.
+type Subscriber struct {
+	js     jetstream.JetStream
+	stream string
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	nakDelay    time.Duration
+	concurrency int
+	maxMessages int
+	// header key where the message id is received.
+	msgIDKey string
+}
+
+// Register adds one or more subscriptions to the Subscriber.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.subs = append(s.subs, subs...)
+}
+
+// Listen starts consuming messages for all registered subscriptions.
+// It blocks until the context is cancelled or a consumer stops with a fatal error, as its deletion,
+// then it stops receiving messages and returns after the messages being processed finish,
+// handlers are not cancelled until Shutdown grace period ends.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen consumes every registered subscription until the context is done
+// or any of the consumers stops with a fatal error, which is returned.
+func (s *Subscriber) listen(ctx, handleCtx context.Context) error {
+	ctx, stopReceiving := context.WithCancel(ctx)
+	defer stopReceiving()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	failures := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case failures <- err:
+		default:
+		}
+		stopReceiving()
+	}
+
+	for _, sub := range s.subs {
+		cons, err := s.js.Consumer(ctx, s.stream, sub.Name())
+		if err != nil {
+			stopReceiving()
+
+			return fmt.Errorf("getting consumer %s: %w", sub.Name(), err)
+		}
+
+		cc, msgs, err := s.consume(ctx, cons, func(err error) {
+			fail(fmt.Errorf("consuming %s: %w", sub.Name(), err))
+		})
+		if err != nil {
+			stopReceiving()
+
+			return fmt.Errorf("consuming %s: %w", sub.Name(), err)
+		}
+
+		wg.Go(func() {
+			<-ctx.Done()
+			cc.Drain()
+			<-cc.Closed()
+			close(msgs)
+		})
+		for range max(s.concurrency, 1) {
+			wg.Go(func() {
+				for msg := range msgs {
+					s.handle(handleCtx, sub, msg)
+				}
+			})
+		}
+	}
+
+	<-ctx.Done()
+
+	select {
+	case err := <-failures:
+		return err
+	default:
+		return nil
+	}
+}
+
+// consume starts receiving the consumer messages, dispatching them to the returned channel.
+// Errors stopping the consumption, see isFatal, are passed to fail, the rest to the error handler.
+func (s *Subscriber) consume(
+	ctx context.Context,
+	cons jetstream.Consumer,
+	fail func(error),
+) (jetstream.ConsumeContext, chan jetstream.Msg, error) {
+	opts := []jetstream.PullConsumeOpt{
+		jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+			if isFatal(err) {
+				fail(err)
+
+				return
+			}
+			s.errHandler.Error(ctx, err)
+		}),
+	}
+	if s.maxMessages > 0 {
+		opts = append(opts, jetstream.PullMaxMessages(s.maxMessages))
+	}
+
+	msgs := make(chan jetstream.Msg)
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		msgs <- msg
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cc, msgs, nil
+}
+
+// isFatal reports whether the consume error stops the consumption,
+// as the consumer was deleted, the pull request rejected or the connection closed.
+func isFatal(err error) bool {
+	return errors.Is(err, jetstream.ErrConsumerDeleted) ||
+		errors.Is(err, jetstream.ErrBadRequest) ||
+		errors.Is(err, jetstream.ErrConnectionClosed)
+}
+
+// handle processes the message with the subscription, acknowledging it on success.
+func (s *Subscriber) handle(ctx context.Context, sub messenger.Subscription, msg jetstream.Msg) {
+	err := sub.Handle(ctx, s.parseMessage(msg))
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("acknowledging message: %w", err))
+		}
+
+		return
+	}
+
+	s.errHandler.Error(ctx, err)
+
+	switch {
+	case messenger.IsPermanent(err):
+		err = msg.Term()
+	case s.nakDelay > 0:
+		err = msg.NakWithDelay(s.nakDelay)
+	default:
+		err = msg.Nak()
+	}
+	if err != nil {
+		s.errHandler.Error(ctx, fmt.Errorf("negatively acknowledging message: %w", err))
+	}
+}
+
+// parseMessage transforms the nats message into a messenger message, restoring the message id
+// from the headers, if it is not present the Nats-Msg-Id header is used.
+func (s *Subscriber) parseMessage(msg jetstream.Msg) *messenger.GenericMessage {
+	header := msg.Headers()
+
+	parsed := messenger.GenericMessage{
+		MsgPayload:  msg.Data(),
+		MsgMetadata: make(map[string]string, len(header)),
+	}
+	for k := range header {
+		switch {
+		case k == s.msgIDKey:
+			parsed.MsgID = header.Get(k)
+		case !strings.HasPrefix(k, reservedHeaderPrefix):
+			parsed.MsgMetadata[k] = header.Get(k)
+		}
+	}
+	if parsed.MsgID == "" {
+		parsed.MsgID = header.Get(jetstream.MsgIDHeader)
+	}
+
+	return &parsed
+}
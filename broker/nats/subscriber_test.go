@@ -0,0 +1,185 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	natsx "github.com/x4b1/messenger/broker/nats"
+)
+
+const consumer = "test-consumer"
+
+// setup creates the durable consumer and publishes the given amount of messages.
+func setup(ctx context.Context, t *testing.T, js jetstream.JetStream, n int) []messenger.Message {
+	t.Helper()
+
+	_, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:    consumer,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    time.Minute,
+		MaxDeliver: -1,
+	})
+	require.NoError(t, err)
+
+	p := natsx.New(js, subject)
+	msgs := make([]messenger.Message, 0, n)
+	for i := range n {
+		msg, err := messenger.NewMessage([]byte(fmt.Sprintf("message %d", i)))
+		require.NoError(t, err)
+		msg.SetMetadata("index", fmt.Sprint(i))
+		require.NoError(t, p.Publish(ctx, msg))
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// pending returns the number of messages not acknowledged by the consumer.
+func pending(ctx context.Context, t *testing.T, js jetstream.JetStream) (int, uint64) {
+	t.Helper()
+
+	cons, err := js.Consumer(ctx, stream, consumer)
+	require.NoError(t, err)
+	info, err := cons.Info(ctx)
+	require.NoError(t, err)
+
+	return info.NumAckPending, info.NumPending
+}
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handles and acks messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		js := newJetStream(ctx, t)
+		msgs := setup(ctx, t, js, 3)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []messenger.Message
+		s := natsx.NewSubscriber(js, stream)
+		s.Register(messenger.NewSubscription(consumer, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg)
+			if len(received) == len(msgs) {
+				stop()
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+
+		require.Len(t, received, len(msgs))
+		for i, msg := range msgs {
+			require.Equal(t, msg.ID(), received[i].ID())
+			require.Equal(t, msg.Payload(), received[i].Payload())
+			require.Equal(t, msg.Metadata(), received[i].Metadata())
+		}
+
+		ackPending, numPending := pending(ctx, t, js)
+		require.Zero(t, ackPending)
+		require.Zero(t, numPending)
+	})
+
+	t.Run("redelivers failed messages after delay", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		js := newJetStream(ctx, t)
+		msgs := setup(ctx, t, js, 1)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var (
+			mu       sync.Mutex
+			received []time.Time
+		)
+		s := natsx.NewSubscriber(js, stream, natsx.WithNakDelay(100*time.Millisecond))
+		s.Register(messenger.NewSubscription(consumer, func(_ context.Context, msg messenger.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			require.Equal(t, msgs[0].ID(), msg.ID())
+			received = append(received, time.Now())
+			if len(received) == 1 {
+				return errors.New("handler error")
+			}
+			stop()
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Len(t, received, 2)
+		require.GreaterOrEqual(t, received[1].Sub(received[0]), 100*time.Millisecond)
+	})
+
+	t.Run("terminates messages failing with permanent error", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		js := newJetStream(ctx, t)
+		msgs := setup(ctx, t, js, 2)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []string
+		s := natsx.NewSubscriber(js, stream)
+		s.Register(messenger.NewSubscription(consumer, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg.ID())
+			if msg.ID() == msgs[0].ID() {
+				return messenger.Permanent(errors.New("invalid message"))
+			}
+			stop()
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID(), msgs[1].ID()}, received)
+
+		ackPending, _ := pending(ctx, t, js)
+		require.Zero(t, ackPending)
+	})
+
+	t.Run("fails with unknown consumer", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		s := natsx.NewSubscriber(newJetStream(ctx, t), stream)
+		s.Register(messenger.NewSubscription("unknown", nil))
+
+		require.ErrorIs(t, s.Listen(ctx), jetstream.ErrConsumerNotFound)
+	})
+	t.Run("fails when the consumer is deleted", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		js := newJetStream(ctx, t)
+		setup(ctx, t, js, 1)
+
+		handled := make(chan struct{})
+		s := natsx.NewSubscriber(js, stream)
+		s.Register(messenger.NewSubscription(consumer, func(context.Context, messenger.Message) error {
+			close(handled)
+
+			return nil
+		}))
+
+		errc := make(chan error, 1)
+		go func() { errc <- s.Listen(ctx) }()
+		<-handled
+		require.NoError(t, js.DeleteConsumer(ctx, stream, consumer))
+
+		require.ErrorIs(t, <-errc, jetstream.ErrConsumerDeleted)
+	})
+}
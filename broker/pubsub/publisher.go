@@ -3,14 +3,19 @@ package pubsub
 
 import (
 	"context"
+	"fmt"
 	"maps"
+	"time"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/x4b1/messenger"
 	"github.com/x4b1/messenger/broker"
 )
 
-var _ broker.Broker = &Publisher{}
+var (
+	_ broker.Broker            = &Publisher{}
+	_ messenger.BatchPublisher = &Publisher{}
+)
 
 // Option is a function to set options to Publisher.
 type Option func(*Publisher)
@@ -38,6 +43,27 @@ func WithMessageIDKey(key string) Option {
 	}
 }
 
+// WithDelayThreshold setups the max time the Publisher waits before sending a non-empty batch of messages.
+func WithDelayThreshold(d time.Duration) Option {
+	return func(p *Publisher) {
+		p.publisher.PublishSettings.DelayThreshold = d
+	}
+}
+
+// WithCountThreshold setups the number of messages that makes the Publisher send a batch.
+func WithCountThreshold(n int) Option {
+	return func(p *Publisher) {
+		p.publisher.PublishSettings.CountThreshold = n
+	}
+}
+
+// WithByteThreshold setups the size in bytes that makes the Publisher send a batch.
+func WithByteThreshold(n int) Option {
+	return func(p *Publisher) {
+		p.publisher.PublishSettings.ByteThreshold = n
+	}
+}
+
 // Open returns a new Publisher instance.
 func Open(pubsubClient *pubsub.Client, topicID string, opts ...Option) *Publisher {
 	return New(pubsubClient.Publisher(topicID), opts...)
@@ -69,18 +95,86 @@ type Publisher struct {
 	msgIDKey string
 }
 
-// Publish publishes the given message to the pubsub topic.
+// Publish publishes the given message to the pubsub topic and waits until it is sent.
+// To benefit from the pubsub client batching publish several messages with PublishBatch.
 func (p Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	return p.wait(ctx, p.send(ctx, msg))
+}
+
+// PublishBatch publishes the given messages to the pubsub topic without waiting each one to be sent,
+// so they are batched by the pubsub client, and then waits all of them.
+// It returns a *messenger.BatchError with the errors of the failed messages.
+func (p Publisher) PublishBatch(ctx context.Context, msgs []messenger.Message) error {
+	results := make([]publishResult, 0, len(msgs))
+	for _, msg := range msgs {
+		results = append(results, p.send(ctx, msg))
+	}
+
+	errs := make(map[string]error)
+	for _, r := range results {
+		if err := p.wait(ctx, r); err != nil {
+			errs[r.msgID] = fmt.Errorf("publishing message %s: %w", r.msgID, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &messenger.BatchError{Errors: errs}
+	}
+
+	return nil
+}
+
+// Close sends the pending messages and stops the pubsub publisher. If the context is done before
+// all messages are sent it returns the context error, the messages keep being sent in background.
+// The Publisher cannot be used once closed.
+func (p Publisher) Close(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		p.publisher.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// publishResult holds the result of a message sent to the pubsub client.
+type publishResult struct {
+	msgID       string
+	orderingKey string
+	res         *pubsub.PublishResult
+}
+
+// send hands the message to the pubsub client without waiting it to be published.
+func (p Publisher) send(ctx context.Context, msg messenger.Message) publishResult {
 	md := make(map[string]string)
 	maps.Copy(md, msg.Metadata())
 
 	md[p.msgIDKey] = msg.ID()
 
-	_, err := p.publisher.Publish(ctx, &pubsub.Message{
-		Attributes:  md,
-		Data:        msg.Payload(),
-		OrderingKey: p.orderingKey(msg),
-	}).Get(ctx)
+	ordKey := p.orderingKey(msg)
+
+	return publishResult{
+		msgID:       msg.ID(),
+		orderingKey: ordKey,
+		res: p.publisher.Publish(ctx, &pubsub.Message{
+			Attributes:  md,
+			Data:        msg.Payload(),
+			OrderingKey: ordKey,
+		}),
+	}
+}
+
+// wait blocks until the message is published. If it fails, it resumes the publishing
+// of the message ordering key, otherwise pubsub client rejects the next messages with the same key.
+func (p Publisher) wait(ctx context.Context, r publishResult) error {
+	_, err := r.res.Get(ctx)
+	if err != nil && r.orderingKey != "" {
+		p.publisher.ResumePublish(r.orderingKey)
+	}
 
 	return err
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/pubsub/v2"
 	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
@@ -15,7 +16,9 @@ import (
 	pubsubpublish "github.com/x4b1/messenger/broker/pubsub"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -29,6 +32,19 @@ func initPubsub(ctx context.Context, t *testing.T) (*pubsub.Publisher, *pstest.S
 	//nolint:errcheck // test file
 	t.Cleanup(func() { srv.Close() })
 
+	client := newClient(ctx, t, srv)
+
+	topic, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{
+		Name: fmt.Sprintf("projects/%s/topics/%s", client.Project(), topicID),
+	})
+	require.NoError(t, err)
+
+	return client.Publisher(topic.GetName()), srv
+}
+
+func newClient(ctx context.Context, t *testing.T, srv *pstest.Server) *pubsub.Client {
+	t.Helper()
+
 	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	require.NoError(t, err)
 	//nolint:errcheck // test file
@@ -39,12 +55,7 @@ func initPubsub(ctx context.Context, t *testing.T) (*pubsub.Publisher, *pstest.S
 	//nolint:errcheck // test file
 	t.Cleanup(func() { client.Close() })
 
-	topic, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{
-		Name: fmt.Sprintf("projects/%s/topics/%s", client.Project(), topicID),
-	})
-	require.NoError(t, err)
-
-	return client.Publisher(topic.GetName()), srv
+	return client
 }
 
 func TestPublishWithNoOrderingKey(t *testing.T) {
@@ -150,3 +161,95 @@ func TestPublishWithCustomMessageID(t *testing.T) {
 		customKey: m.MsgID,
 	}, msgs[0].Attributes)
 }
+
+func TestPublishBatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	publisher, srv := initPubsub(ctx, t)
+
+	msgs := make([]messenger.Message, 0, 3)
+	for range 3 {
+		m, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		msgs = append(msgs, m)
+	}
+
+	require.NoError(t, pubsubpublish.New(publisher).PublishBatch(ctx, msgs))
+
+	published := srv.Messages()
+	require.Len(t, published, 3)
+	ids := make([]string, 0, len(published))
+	for _, m := range published {
+		ids = append(ids, m.Attributes[broker.MessageIDKey])
+	}
+	require.ElementsMatch(t, []string{msgs[0].ID(), msgs[1].ID(), msgs[2].ID()}, ids)
+}
+
+func TestPublishBatchReturnsFailedMessages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	publisher, srv := initPubsub(ctx, t)
+	p := pubsubpublish.New(publisher, pubsubpublish.WithMetaOrderingKey("key"))
+
+	srv.SetAutoPublishResponse(false)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "invalid message"))
+	srv.AddPublishResponse(&pubsubpb.PublishResponse{MessageIds: []string{"1"}}, nil)
+
+	msgs := make([]messenger.Message, 0, 2)
+	for _, key := range []string{"a", "b"} {
+		m, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		msgs = append(msgs, m.SetMetadata("key", key))
+	}
+
+	var batchErr *messenger.BatchError
+	require.ErrorAs(t, p.PublishBatch(ctx, msgs), &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+}
+
+func TestCloseFlushesPendingMessages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	publisher, srv := initPubsub(ctx, t)
+	p := pubsubpublish.New(
+		publisher,
+		pubsubpublish.WithDelayThreshold(time.Hour),
+		pubsubpublish.WithCountThreshold(1000),
+		pubsubpublish.WithByteThreshold(1e7),
+	)
+
+	m, err := messenger.NewMessage([]byte("some message"))
+	require.NoError(t, err)
+
+	published := make(chan error, 1)
+	go func() { published <- p.Publish(ctx, m) }()
+
+	require.Never(t, func() bool { return len(srv.Messages()) > 0 }, 50*time.Millisecond, 10*time.Millisecond)
+
+	require.NoError(t, p.Close(ctx))
+	require.NoError(t, <-published)
+	require.Len(t, srv.Messages(), 1)
+
+	require.Error(t, p.Publish(ctx, m))
+}
+
+func TestPublishResumesOrderingKeyOnError(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	publisher, srv := initPubsub(ctx, t)
+	p := pubsubpublish.New(publisher, pubsubpublish.WithDefaultOrderingKey("default-ord-key"))
+
+	srv.SetAutoPublishResponse(false)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "invalid message"))
+	srv.AddPublishResponse(&pubsubpb.PublishResponse{MessageIds: []string{"1"}}, nil)
+
+	m, err := messenger.NewMessage([]byte("some message"))
+	require.NoError(t, err)
+
+	require.Error(t, p.Publish(ctx, m))
+	require.NoError(t, p.Publish(ctx, m))
+}
@@ -0,0 +1,151 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+	"golang.org/x/sync/errgroup"
+)
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// SubscriberOption is a function to set options to Subscriber.
+type SubscriberOption func(*Subscriber)
+
+// WithSubscriberMessageIDKey modify default attribute key where the message id is received.
+func WithSubscriberMessageIDKey(key string) SubscriberOption {
+	return func(s *Subscriber) {
+		s.msgIDKey = key
+	}
+}
+
+// WithMaxOutstandingMessages setups the max number of messages being processed at the same time
+// by every subscription of the Subscriber. A negative value removes the limit.
+func WithMaxOutstandingMessages(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.settings.MaxOutstandingMessages = n
+	}
+}
+
+// WithMaxOutstandingBytes setups the max size of the messages being processed at the same time
+// by every subscription of the Subscriber. A negative value removes the limit.
+func WithMaxOutstandingBytes(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.settings.MaxOutstandingBytes = n
+	}
+}
+
+// WithNumGoroutines setups the number of streams pulling messages for every subscription of the Subscriber.
+// It does not limit the messages processed at the same time, see WithMaxOutstandingMessages.
+func WithNumGoroutines(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.settings.NumGoroutines = n
+	}
+}
+
+// NewSubscriber returns a new Subscriber instance, the registered subscriptions names
+// are the pubsub subscriptions ids or full names.
+func NewSubscriber(client *pubsub.Client, opts ...SubscriberOption) *Subscriber {
+	s := Subscriber{
+		client:     client,
+		subs:       make([]messenger.Subscription, 0),
+		errHandler: log.NewDefault(),
+		settings:   pubsub.DefaultReceiveSettings,
+		msgIDKey:   broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Subscriber receives the messages of pubsub subscriptions and handles them with the registered subscriptions.
+// Messages are acknowledged once handled, or negatively acknowledged if the handler fails.
+type Subscriber struct {
+	client *pubsub.Client
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	// flow control and concurrency settings for every subscription.
+	settings pubsub.ReceiveSettings
+	// metadata key where the message id is received.
+	msgIDKey string
+}
+
+// Register adds one or more subscriptions to the Subscriber.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.subs = append(s.subs, subs...)
+}
+
+// Listen starts receiving messages for all registered subscriptions.
+// It blocks until the context is cancelled or any subscription fails.
+// Once the context is cancelled it stops receiving messages and returns after
+// the messages being processed finish, handlers are not cancelled until Shutdown grace period ends.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen receives the messages of every registered subscription until all of them stop receiving.
+func (s *Subscriber) listen(ctx, handleCtx context.Context) error {
+	group, ctx := errgroup.WithContext(ctx)
+	for _, sub := range s.subs {
+		ps := s.client.Subscriber(sub.Name())
+		ps.ReceiveSettings = s.settings
+
+		group.Go(func() error {
+			err := ps.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
+				s.handle(handleCtx, sub, msg)
+			})
+			if err != nil {
+				return fmt.Errorf("receiving messages for %s: %w", sub.Name(), err)
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// handle processes the message with the subscription, acknowledging it on success.
+func (s *Subscriber) handle(ctx context.Context, sub messenger.Subscription, msg *pubsub.Message) {
+	if err := sub.Handle(ctx, s.parseMessage(msg)); err != nil {
+		s.errHandler.Error(ctx, err)
+		msg.Nack()
+
+		return
+	}
+
+	msg.Ack()
+}
+
+// parseMessage transforms the pubsub message into a messenger message, restoring the message id
+// from the attributes.
+func (s *Subscriber) parseMessage(msg *pubsub.Message) *messenger.GenericMessage {
+	parsed := messenger.GenericMessage{
+		MsgPayload:  msg.Data,
+		MsgMetadata: make(map[string]string, len(msg.Attributes)),
+	}
+	for k, v := range msg.Attributes {
+		if k == s.msgIDKey {
+			parsed.MsgID = v
+			continue
+		}
+		parsed.MsgMetadata[k] = v
+	}
+	if parsed.MsgID == "" {
+		parsed.MsgID = msg.ID
+	}
+
+	return &parsed
+}
@@ -0,0 +1,171 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/v2/pstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	pubsubpublish "github.com/x4b1/messenger/broker/pubsub"
+)
+
+const subscriptionID = "test-subscription"
+
+// initSubscriber returns a publisher and a subscriber connected to the same topic through a subscription.
+func initSubscriber(
+	ctx context.Context,
+	t *testing.T,
+	pubOpts []pubsubpublish.Option,
+	subOpts ...pubsubpublish.SubscriberOption,
+) (*pubsubpublish.Publisher, *pubsubpublish.Subscriber, *pstest.Server) {
+	t.Helper()
+
+	publisher, srv := initPubsub(ctx, t)
+
+	client := newClient(ctx, t, srv)
+	_, err := srv.GServer.CreateSubscription(ctx, &pubsubpb.Subscription{
+		Name:               fmt.Sprintf("projects/%s/subscriptions/%s", client.Project(), subscriptionID),
+		Topic:              publisher.String(),
+		AckDeadlineSeconds: 10,
+	})
+	require.NoError(t, err)
+
+	return pubsubpublish.New(publisher, pubOpts...), pubsubpublish.NewSubscriber(client, subOpts...), srv
+}
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handles and acks messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		customKey := "custom_key"
+		publisher, subscriber, srv := initSubscriber(
+			ctx,
+			t,
+			[]pubsubpublish.Option{pubsubpublish.WithMessageIDKey(customKey)},
+			pubsubpublish.WithSubscriberMessageIDKey(customKey),
+		)
+
+		m := &messenger.GenericMessage{
+			MsgID:       uuid.NewString(),
+			MsgMetadata: map[string]string{"aggregate_id": "29a7556a-ae85-4c1d-8f04-d57ed3122586"},
+			MsgPayload:  []byte("some message"),
+		}
+		require.NoError(t, publisher.Publish(ctx, m))
+
+		received := make(chan messenger.Message, 1)
+		subscriber.Register(messenger.NewSubscription(subscriptionID, func(_ context.Context, msg messenger.Message) error {
+			received <- msg
+			cancel()
+
+			return nil
+		}))
+
+		require.NoError(t, subscriber.Listen(ctx))
+
+		msg := <-received
+		require.Equal(t, m.MsgID, msg.ID())
+		require.Equal(t, m.Payload(), msg.Payload())
+		require.Equal(t, m.Metadata(), msg.Metadata())
+
+		require.Eventually(t, func() bool {
+			return srv.Messages()[0].Acks == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("nacks failed messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		publisher, subscriber, srv := initSubscriber(ctx, t, nil)
+
+		m, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		require.NoError(t, publisher.Publish(ctx, m))
+
+		var (
+			mu       sync.Mutex
+			attempts int
+		)
+		subscriber.Register(messenger.NewSubscription(subscriptionID, func(context.Context, messenger.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			if attempts == 2 {
+				cancel()
+				return nil
+			}
+
+			return errors.New("handler error")
+		}))
+
+		require.NoError(t, subscriber.Listen(ctx))
+		require.Equal(t, 2, attempts)
+
+		msgs := srv.Messages()
+		require.GreaterOrEqual(t, msgs[0].Deliveries, 2)
+		require.Contains(t, deadlines(msgs[0].Modacks), int32(0))
+	})
+
+	t.Run("fails with unknown subscription", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		_, subscriber, _ := initSubscriber(ctx, t, nil)
+		subscriber.Register(messenger.NewSubscription("unknown", func(context.Context, messenger.Message) error {
+			return nil
+		}))
+
+		require.Error(t, subscriber.Listen(ctx))
+	})
+
+	t.Run("shutdown waits messages being processed", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		publisher, subscriber, srv := initSubscriber(ctx, t, nil, pubsubpublish.WithMaxOutstandingMessages(1))
+
+		m, err := messenger.NewMessage([]byte("some message"))
+		require.NoError(t, err)
+		require.NoError(t, publisher.Publish(ctx, m))
+
+		handling := make(chan struct{})
+		subscriber.Register(messenger.NewSubscription(subscriptionID, func(ctx context.Context, _ messenger.Message) error {
+			close(handling)
+			time.Sleep(50 * time.Millisecond)
+
+			return ctx.Err()
+		}))
+
+		listenErr := make(chan error, 1)
+		go func() { listenErr <- subscriber.Listen(ctx) }()
+
+		<-handling
+		require.NoError(t, subscriber.Shutdown(ctx))
+		require.NoError(t, <-listenErr)
+
+		require.Eventually(t, func() bool {
+			return srv.Messages()[0].Acks == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func deadlines(modacks []pstest.Modack) []int32 {
+	d := make([]int32, 0, len(modacks))
+	for _, m := range modacks {
+		d = append(d, m.AckDeadline)
+	}
+
+	return d
+}
@@ -0,0 +1,106 @@
+// Package redis Redis Streams broker implementation
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+)
+
+// PayloadField is the stream entry field where the message payload is sent,
+// message metadata must not use it as key.
+const PayloadField = "payload"
+
+// ErrReservedField is returned when the message metadata uses the payload or message id fields as key.
+var ErrReservedField = errors.New("metadata key is a reserved field")
+
+var _ broker.Broker = &Publisher{}
+
+// Option is a function to set options to Publisher or Subscriber.
+type Option func(any)
+
+// WithMaxLen setups the approximate max number of entries kept in the stream, older entries are trimmed.
+func WithMaxLen(n int64) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.maxLen = n
+	}
+}
+
+// WithMessageIDKey modify default message id field, used by Publisher and Subscriber.
+func WithMessageIDKey(key string) Option {
+	return func(c any) {
+		switch v := c.(type) {
+		case *Publisher:
+			v.msgIDKey = key
+		case *Subscriber:
+			v.msgIDKey = key
+		}
+	}
+}
+
+// New returns a new Publisher instance that appends the messages to the given stream.
+func New(client redis.StreamCmdable, stream string, opts ...Option) *Publisher {
+	p := Publisher{
+		client:   client,
+		stream:   stream,
+		msgIDKey: broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return &p
+}
+
+// Publisher appends messages to a Redis stream.
+type Publisher struct {
+	// redis client used to append the entries
+	client redis.StreamCmdable
+	// stream where are going to publish messages
+	stream string
+	// approximate max length of the stream, 0 disables trimming
+	maxLen int64
+	// field where will be send the message id.
+	msgIDKey string
+}
+
+// Publish appends the given message to the stream with XADD.
+// Message metadata and id are sent as entry fields along with the payload,
+// it returns ErrReservedField if a metadata key matches the payload or message id field.
+func (p Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	md := msg.Metadata()
+	for _, k := range []string{PayloadField, p.msgIDKey} {
+		if _, ok := md[k]; ok {
+			return fmt.Errorf("%w: %s", ErrReservedField, k)
+		}
+	}
+
+	values := make([]any, 0, 2*(len(md)+2))
+	for _, k := range slices.Sorted(maps.Keys(md)) {
+		values = append(values, k, md[k])
+	}
+	values = append(values, p.msgIDKey, msg.ID(), PayloadField, msg.Payload())
+
+	err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: values,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("publishing message: %w", err)
+	}
+
+	return nil
+}
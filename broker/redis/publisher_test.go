@@ -0,0 +1,96 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	redisx "github.com/x4b1/messenger/broker/redis"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	m := &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"aggregate_id": "29a7556a-ae85-4c1d-8f04-d57ed3122586"},
+		MsgPayload:  []byte("some message"),
+	}
+
+	for _, tc := range []struct {
+		name          string
+		opts          []redisx.Option
+		expectedIDKey string
+	}{
+		{
+			name:          "default message id key",
+			expectedIDKey: broker.MessageIDKey,
+		},
+		{
+			name:          "custom message id key",
+			opts:          []redisx.Option{redisx.WithMessageIDKey("custom_key")},
+			expectedIDKey: "custom_key",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			client := newClient(t)
+			require.NoError(t, redisx.New(client, stream, tc.opts...).Publish(ctx, m))
+
+			entries, err := client.XRange(ctx, stream, "-", "+").Result()
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.Equal(t, map[string]any{
+				"aggregate_id":      m.MsgMetadata["aggregate_id"],
+				tc.expectedIDKey:    m.MsgID,
+				redisx.PayloadField: string(m.MsgPayload),
+			}, entries[0].Values)
+		})
+	}
+
+	t.Run("trims stream", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		client := newClient(t)
+		p := redisx.New(client, stream, redisx.WithMaxLen(2))
+		for range 5 {
+			require.NoError(t, p.Publish(ctx, m))
+		}
+
+		n, err := client.XLen(ctx, stream).Result()
+		require.NoError(t, err)
+		require.LessOrEqual(t, n, int64(2))
+	})
+
+	for _, key := range []string{redisx.PayloadField, broker.MessageIDKey} {
+		t.Run("rejects reserved metadata key "+key, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			msg, err := messenger.NewMessage([]byte("some message"))
+			require.NoError(t, err)
+			msg.SetMetadata(key, "value")
+
+			client := newClient(t)
+			require.ErrorIs(t, redisx.New(client, stream).Publish(ctx, msg), redisx.ErrReservedField)
+
+			n, err := client.XLen(ctx, stream).Result()
+			require.NoError(t, err)
+			require.Zero(t, n)
+		})
+	}
+
+	t.Run("fails", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, redisx.New(newClient(t), stream).Publish(ctx, m), context.Canceled)
+	})
+}
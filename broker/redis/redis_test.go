@@ -0,0 +1,22 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const stream = "events"
+
+// newClient starts an in memory redis server and returns a client connected to it.
+func newClient(t *testing.T) *redis.Client {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	//nolint:errcheck // test file
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
@@ -0,0 +1,321 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"github.com/x4b1/messenger/log"
+)
+
+const (
+	defaultBatchSize    = 10
+	defaultBlock        = 5 * time.Second
+	defaultClaimMinIdle = time.Minute
+)
+
+var _ messenger.Subscriber = &Subscriber{}
+
+// WithBatchSize setups the max number of entries read or claimed at once.
+func WithBatchSize(n int64) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.batchSize = n
+	}
+}
+
+// WithBlock setups the max time a read waits for new entries.
+func WithBlock(d time.Duration) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.block = d
+	}
+}
+
+// WithClaimMinIdle setups the time a delivered entry has to be pending to be claimed by the Subscriber,
+// entries are pending when the handler fails or the consumer that received them stopped.
+func WithClaimMinIdle(d time.Duration) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.claimMinIdle = d
+	}
+}
+
+// WithDeadLetterStream setups the stream where the entries delivered more than maxDeliveries times are sent
+// instead of being handled again, they are acknowledged once sent. Failed entries are delivered again when they
+// are claimed, so a maxDeliveries below 1 sends them on their first claim.
+func WithDeadLetterStream(stream string, maxDeliveries int64) Option {
+	return func(c any) {
+		s, ok := c.(*Subscriber)
+		if !ok {
+			return
+		}
+		s.deadLetterStream = stream
+		s.maxDeliveries = maxDeliveries
+	}
+}
+
+// NewSubscriber returns a new Subscriber instance that reads the streams as the given consumer of the group,
+// the registered subscriptions names are the streams to read. The group is created if it does not exist.
+func NewSubscriber(client redis.StreamCmdable, group, consumer string, opts ...Option) *Subscriber {
+	s := Subscriber{
+		client:       client,
+		group:        group,
+		consumer:     consumer,
+		subs:         make([]messenger.Subscription, 0),
+		errHandler:   log.NewDefault(),
+		batchSize:    defaultBatchSize,
+		block:        defaultBlock,
+		claimMinIdle: defaultClaimMinIdle,
+		msgIDKey:     broker.MessageIDKey,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Subscriber reads Redis streams as a member of a consumer group and handles the entries with the
+// registered subscriptions. Entries are acknowledged once handled, failed entries are kept pending
+// and claimed again once they have been idle for the claim min idle time, unless the error is permanent,
+// in which case they are acknowledged. With a dead letter stream, the entries that exceed the max deliveries
+// are sent to it when claimed.
+type Subscriber struct {
+	client   redis.StreamCmdable
+	group    string
+	consumer string
+
+	errHandler messenger.ErrorHandler
+	subs       []messenger.Subscription
+
+	// stops the listening process gracefully, providing Shutdown and Close.
+	broker.Lifecycle
+
+	batchSize    int64
+	block        time.Duration
+	claimMinIdle time.Duration
+	// stream where the entries delivered more than maxDeliveries times are sent, empty disables it.
+	deadLetterStream string
+	maxDeliveries    int64
+	// field where the message id is received.
+	msgIDKey string
+}
+
+// Register adds one or more subscriptions to the Subscriber.
+func (s *Subscriber) Register(subs ...messenger.Subscription) {
+	s.subs = append(s.subs, subs...)
+}
+
+// Listen starts reading the streams of all registered subscriptions.
+// It blocks until the context is cancelled or reading any stream fails. Once the context is cancelled
+// it stops reading and returns after the entries being processed finish, handlers are not cancelled
+// until Shutdown grace period ends.
+func (s *Subscriber) Listen(ctx context.Context) error {
+	return s.Lifecycle.Listen(ctx, s.listen)
+}
+
+// listen reads every registered subscription stream until the context is done or any of them fails.
+func (s *Subscriber) listen(ctx, handleCtx context.Context) error {
+	ctx, stopReceiving := context.WithCancel(ctx)
+	defer stopReceiving()
+
+	for _, sub := range s.subs {
+		if err := s.ensureGroup(ctx, sub.Name()); err != nil {
+			return err
+		}
+	}
+
+	var (
+		wg    sync.WaitGroup
+		errMu sync.Mutex
+		errs  []error
+	)
+	for _, sub := range s.subs {
+		wg.Go(func() {
+			if err := s.consume(ctx, handleCtx, sub); err != nil {
+				errMu.Lock()
+				errs = append(errs, err)
+				errMu.Unlock()
+				stopReceiving()
+			}
+		})
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// ensureGroup creates the consumer group reading the stream from the beginning, and the stream if needed.
+func (s *Subscriber) ensureGroup(ctx context.Context, stream string) error {
+	err := s.client.XGroupCreateMkStream(ctx, stream, s.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("creating consumer group for %s: %w", stream, err)
+	}
+
+	return nil
+}
+
+// consume reads the new entries of the stream and claims the stale pending ones until the context is done.
+func (s *Subscriber) consume(ctx, handleCtx context.Context, sub messenger.Subscription) error {
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= s.claimMinIdle {
+			if err := s.claim(ctx, handleCtx, sub); err != nil {
+				return err
+			}
+			lastClaim = time.Now()
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.group,
+			Consumer: s.consumer,
+			Streams:  []string{sub.Name(), ">"},
+			Count:    s.batchSize,
+			Block:    s.block,
+		}).Result()
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, redis.Nil):
+			continue
+		case err != nil:
+			return fmt.Errorf("reading %s: %w", sub.Name(), err)
+		}
+
+		for _, stream := range streams {
+			s.handle(handleCtx, sub, stream.Messages)
+		}
+	}
+
+	return nil
+}
+
+// claim takes and handles the entries of the stream pending for longer than the claim min idle time.
+func (s *Subscriber) claim(ctx, handleCtx context.Context, sub messenger.Subscription) error {
+	start := "0-0"
+	for {
+		msgs, next, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   sub.Name(),
+			Group:    s.group,
+			Consumer: s.consumer,
+			MinIdle:  s.claimMinIdle,
+			Start:    start,
+			Count:    s.batchSize,
+		}).Result()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("claiming pending entries of %s: %w", sub.Name(), err)
+		}
+
+		s.handle(handleCtx, sub, s.deadLetter(handleCtx, sub.Name(), msgs))
+
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// handle processes the entries with the subscription, acknowledging the handled ones.
+func (s *Subscriber) handle(ctx context.Context, sub messenger.Subscription, msgs []redis.XMessage) {
+	for _, msg := range msgs {
+		if err := sub.Handle(ctx, s.parseMessage(msg)); err != nil {
+			s.errHandler.Error(ctx, err)
+			if !messenger.IsPermanent(err) {
+				continue
+			}
+		}
+
+		if err := s.client.XAck(ctx, sub.Name(), s.group, msg.ID).Err(); err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("acknowledging entry %s: %w", msg.ID, err))
+		}
+	}
+}
+
+// deadLetter sends the claimed entries delivered more than the max deliveries to the dead letter stream,
+// acknowledging them, and returns the entries to handle.
+func (s *Subscriber) deadLetter(ctx context.Context, stream string, msgs []redis.XMessage) []redis.XMessage {
+	if s.deadLetterStream == "" {
+		return msgs
+	}
+
+	toHandle := make([]redis.XMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   stream,
+			Group:    s.group,
+			Start:    msg.ID,
+			End:      msg.ID,
+			Count:    1,
+			Consumer: s.consumer,
+		}).Result()
+		if err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("getting deliveries of entry %s: %w", msg.ID, err))
+			continue
+		}
+		if len(pending) == 0 || pending[0].RetryCount <= s.maxDeliveries {
+			toHandle = append(toHandle, msg)
+			continue
+		}
+
+		values := make([]any, 0, 2*len(msg.Values))
+		for _, k := range slices.Sorted(maps.Keys(msg.Values)) {
+			values = append(values, k, msg.Values[k])
+		}
+		err = s.client.XAdd(ctx, &redis.XAddArgs{Stream: s.deadLetterStream, Values: values}).Err()
+		if err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("sending entry %s to dead letter stream: %w", msg.ID, err))
+			continue
+		}
+		if err := s.client.XAck(ctx, stream, s.group, msg.ID).Err(); err != nil {
+			s.errHandler.Error(ctx, fmt.Errorf("acknowledging entry %s: %w", msg.ID, err))
+		}
+	}
+
+	return toHandle
+}
+
+// parseMessage transforms the stream entry into a messenger message, restoring the message id
+// from the fields, if it is not present the entry id is used.
+func (s *Subscriber) parseMessage(msg redis.XMessage) *messenger.GenericMessage {
+	parsed := messenger.GenericMessage{
+		MsgMetadata: make(map[string]string, len(msg.Values)),
+	}
+	for k, v := range msg.Values {
+		value := fmt.Sprint(v)
+		switch k {
+		case PayloadField:
+			parsed.MsgPayload = []byte(value)
+		case s.msgIDKey:
+			parsed.MsgID = value
+		default:
+			parsed.MsgMetadata[k] = value
+		}
+	}
+	if parsed.MsgID == "" {
+		parsed.MsgID = msg.ID
+	}
+
+	return &parsed
+}
@@ -0,0 +1,231 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	redisx "github.com/x4b1/messenger/broker/redis"
+)
+
+const (
+	group    = "test-group"
+	consumer = "test-consumer"
+)
+
+// publish appends the given amount of messages to the test stream.
+func publish(ctx context.Context, t *testing.T, client *redis.Client, n int) []messenger.Message {
+	t.Helper()
+
+	p := redisx.New(client, stream)
+	msgs := make([]messenger.Message, 0, n)
+	for i := range n {
+		msg, err := messenger.NewMessage([]byte(fmt.Sprintf("message %d", i)))
+		require.NoError(t, err)
+		msg.SetMetadata("index", fmt.Sprint(i))
+		require.NoError(t, p.Publish(ctx, msg))
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// pending returns the number of entries delivered to the group and not acknowledged.
+func pending(ctx context.Context, t *testing.T, client *redis.Client) int64 {
+	t.Helper()
+
+	p, err := client.XPending(ctx, stream, group).Result()
+	require.NoError(t, err)
+
+	return p.Count
+}
+
+func TestSubscriber(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handles and acks messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := newClient(t)
+		msgs := publish(ctx, t, client, 3)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []messenger.Message
+		s := redisx.NewSubscriber(client, group, consumer, redisx.WithBlock(10*time.Millisecond))
+		s.Register(messenger.NewSubscription(stream, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg)
+			if len(received) == len(msgs) {
+				stop()
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+
+		require.Len(t, received, len(msgs))
+		for i, msg := range msgs {
+			require.Equal(t, msg.ID(), received[i].ID())
+			require.Equal(t, msg.Payload(), received[i].Payload())
+			require.Equal(t, msg.Metadata(), received[i].Metadata())
+		}
+		require.Zero(t, pending(ctx, t, client))
+	})
+
+	t.Run("claims failed messages", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := newClient(t)
+		msgs := publish(ctx, t, client, 2)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var (
+			mu       sync.Mutex
+			received []string
+		)
+		s := redisx.NewSubscriber(
+			client, group, consumer,
+			redisx.WithBlock(10*time.Millisecond),
+			redisx.WithClaimMinIdle(50*time.Millisecond),
+		)
+		s.Register(messenger.NewSubscription(stream, func(_ context.Context, msg messenger.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, msg.ID())
+			if len(received) == 1 {
+				return errors.New("handler error")
+			}
+			if len(received) == 3 {
+				stop()
+			}
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID(), msgs[1].ID(), msgs[0].ID()}, received)
+		require.Zero(t, pending(ctx, t, client))
+	})
+
+	t.Run("sends messages exceeding max deliveries to dead letter stream", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := newClient(t)
+		msg := publish(ctx, t, client, 1)[0]
+
+		var (
+			mu    sync.Mutex
+			calls int
+		)
+		s := redisx.NewSubscriber(
+			client, group, consumer,
+			redisx.WithBlock(10*time.Millisecond),
+			redisx.WithClaimMinIdle(20*time.Millisecond),
+			redisx.WithDeadLetterStream("dead-letter", 2),
+		)
+		s.Register(messenger.NewSubscription(stream, func(context.Context, messenger.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+
+			return errors.New("handler error")
+		}))
+
+		listenCtx, stop := context.WithCancel(ctx)
+		errCh := make(chan error, 1)
+		go func() { errCh <- s.Listen(listenCtx) }()
+
+		require.Eventually(t, func() bool {
+			n, err := client.XLen(ctx, "dead-letter").Result()
+			return err == nil && n == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		stop()
+		require.NoError(t, <-errCh)
+
+		require.Equal(t, 2, calls)
+		require.Zero(t, pending(ctx, t, client))
+
+		entries, err := client.XRange(ctx, "dead-letter", "-", "+").Result()
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"index":             "0",
+			broker.MessageIDKey: msg.ID(),
+			redisx.PayloadField: string(msg.Payload()),
+		}, entries[0].Values)
+	})
+
+	t.Run("claims messages of stopped consumers", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := newClient(t)
+		msgs := publish(ctx, t, client, 1)
+
+		require.NoError(t, client.XGroupCreateMkStream(ctx, stream, group, "0").Err())
+		_, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: "stopped-consumer",
+			Streams:  []string{stream, ">"},
+		}).Result()
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []string
+		s := redisx.NewSubscriber(
+			client, group, consumer,
+			redisx.WithBlock(10*time.Millisecond),
+			redisx.WithClaimMinIdle(10*time.Millisecond),
+		)
+		s.Register(messenger.NewSubscription(stream, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg.ID())
+			stop()
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID()}, received)
+		require.Zero(t, pending(ctx, t, client))
+	})
+
+	t.Run("acks messages failing with permanent error", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := newClient(t)
+		msgs := publish(ctx, t, client, 2)
+
+		listenCtx, stop := context.WithCancel(ctx)
+		var received []string
+		s := redisx.NewSubscriber(client, group, consumer, redisx.WithBlock(10*time.Millisecond))
+		s.Register(messenger.NewSubscription(stream, func(_ context.Context, msg messenger.Message) error {
+			received = append(received, msg.ID())
+			if msg.ID() == msgs[0].ID() {
+				return messenger.Permanent(errors.New("invalid message"))
+			}
+			stop()
+
+			return nil
+		}))
+
+		require.NoError(t, s.Listen(listenCtx))
+		require.Equal(t, []string{msgs[0].ID(), msgs[1].ID()}, received)
+		require.Zero(t, pending(ctx, t, client))
+	})
+}
@@ -0,0 +1,279 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	defaultContentType = "application/json"
+	// maxRetryBackoff is the limit of the exponential backoff between attempts.
+	maxRetryBackoff = time.Minute
+)
+
+// Errors returned when the message cannot be sent as a request.
+var (
+	ErrMissingURL    = errors.New("missing webhook url")
+	ErrInvalidHeader = errors.New("invalid webhook header")
+)
+
+var _ broker.Broker = &Publisher{}
+
+// URLResolver returns the endpoint where the given message is delivered.
+type URLResolver func(ctx context.Context, msg messenger.Message) (string, error)
+
+// StatusError is returned when the endpoint responds with a non 2xx status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook %s responded with status %d", e.URL, e.StatusCode)
+}
+
+// WithMetaURLKey setups the metadata key to get the message endpoint,
+// if the message does not have it the Publisher url is used.
+func WithMetaURLKey(key string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.metaURLKey = key
+	}
+}
+
+// WithURLResolver setups a function to resolve the endpoint of every message,
+// it takes precedence over the metadata key and the Publisher url.
+func WithURLResolver(fn URLResolver) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.resolver = fn
+	}
+}
+
+// WithHTTPClient setups the client used to send the requests, by default http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.client = client
+	}
+}
+
+// WithRetries setups the max attempts to deliver a message failing with a transient error,
+// waiting between attempts an exponential backoff starting with the given duration, doubling it up to
+// a minute or the given duration if greater.
+func WithRetries(maxAttempts int, backoff time.Duration) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.maxAttempts = maxAttempts
+		p.backoff = backoff
+	}
+}
+
+// WithContentType modify default request content type, application/json.
+func WithContentType(contentType string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.contentType = contentType
+	}
+}
+
+// WithMessageIDKey modify default header where the message id is sent, so receivers can discard duplicates.
+func WithMessageIDKey(key string) Option {
+	return func(c any) {
+		p, ok := c.(*Publisher)
+		if !ok {
+			return
+		}
+		p.msgIDKey = key
+	}
+}
+
+// New returns a new Publisher instance that signs the requests with the given secret,
+// the url is used when not resolved from the message.
+func New(url string, secret []byte, opts ...Option) *Publisher {
+	p := Publisher{
+		url:             url,
+		secret:          secret,
+		client:          http.DefaultClient,
+		maxAttempts:     1,
+		contentType:     defaultContentType,
+		signatureHeader: DefaultSignatureHeader,
+		timestampHeader: DefaultTimestampHeader,
+		msgIDKey:        DefaultIdempotencyHeader,
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return &p
+}
+
+// Publisher delivers messages to HTTP endpoints as signed POST requests.
+// Client errors responses are returned as permanent errors, as retrying the request will not fix them,
+// while server errors, timeouts and rate limits are retried when configured.
+type Publisher struct {
+	client *http.Client
+	secret []byte
+
+	// default endpoint in case not resolved from the message.
+	url string
+	// meta property of the message to use as endpoint.
+	metaURLKey string
+	resolver   URLResolver
+
+	maxAttempts int
+	backoff     time.Duration
+
+	contentType     string
+	signatureHeader string
+	timestampHeader string
+	// header key where will be send the message id.
+	msgIDKey string
+}
+
+// Publish sends the message payload to its endpoint, message metadata is sent as request headers.
+// Metadata that are not valid headers are rejected with a permanent ErrInvalidHeader.
+func (p Publisher) Publish(ctx context.Context, msg messenger.Message) error {
+	if err := validateHeaders(msg.Metadata()); err != nil {
+		return err
+	}
+
+	url, err := p.messageURL(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	for attempt := range max(p.maxAttempts, 1) {
+		if attempt > 0 {
+			t := time.NewTimer(retryBackoff(p.backoff, attempt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+
+				return errors.Join(err, ctx.Err())
+			case <-t.C:
+			}
+		}
+
+		if err = p.send(ctx, url, msg); err == nil || messenger.IsPermanent(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// send delivers the message once, signing the request with the current time.
+func (p Publisher) send(ctx context.Context, url string, msg messenger.Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Payload()))
+	if err != nil {
+		return messenger.Permanent(fmt.Errorf("creating request: %w", err))
+	}
+
+	for k, v := range msg.Metadata() {
+		req.Header.Set(k, v)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", p.contentType)
+	req.Header.Set(p.msgIDKey, msg.ID())
+	req.Header.Set(p.timestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(p.signatureHeader, sign(p.secret, now, msg.Payload()))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	//nolint:errcheck // the body is drained to reuse the connection.
+	defer res.Body.Close()
+	//nolint:errcheck // the body is drained to reuse the connection.
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	err = &StatusError{URL: url, StatusCode: res.StatusCode}
+	if isTransient(res.StatusCode) {
+		return err
+	}
+
+	return messenger.Permanent(err)
+}
+
+// messageURL resolves the message endpoint, with the resolver, from message metadata
+// or defaulting to Publisher setup.
+func (p Publisher) messageURL(ctx context.Context, msg messenger.Message) (string, error) {
+	if p.resolver != nil {
+		url, err := p.resolver(ctx, msg)
+		if err != nil {
+			return "", fmt.Errorf("resolving webhook url: %w", err)
+		}
+
+		return url, nil
+	}
+
+	if url, ok := msg.Metadata()[p.metaURLKey]; ok && url != "" {
+		return url, nil
+	}
+
+	if p.url == "" {
+		return "", ErrMissingURL
+	}
+
+	return p.url, nil
+}
+
+// retryBackoff returns the time to wait before the given attempt, the first retry is attempt 1.
+func retryBackoff(backoff time.Duration, attempt int) time.Duration {
+	limit := max(backoff, maxRetryBackoff)
+	for i := 1; i < attempt && backoff < limit; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, limit)
+}
+
+// validateHeaders checks that the metadata can be sent as request headers,
+// as sending them would fail on every attempt.
+func validateHeaders(md messenger.Metadata) error {
+	for k, v := range md {
+		if !httpguts.ValidHeaderFieldName(k) || !httpguts.ValidHeaderFieldValue(v) {
+			return messenger.Permanent(fmt.Errorf("%w: %q", ErrInvalidHeader, k))
+		}
+	}
+
+	return nil
+}
+
+// isTransient reports whether the request can succeed if it is sent again.
+func isTransient(code int) bool {
+	return code >= http.StatusInternalServerError ||
+		code == http.StatusRequestTimeout ||
+		code == http.StatusTooManyRequests
+}
@@ -0,0 +1,209 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/broker/webhook"
+)
+
+var secret = []byte("secret")
+
+func newMessage() *messenger.GenericMessage {
+	return &messenger.GenericMessage{
+		MsgID:       uuid.NewString(),
+		MsgMetadata: map[string]string{"Aggregate-Id": "29a7556a-ae85-4c1d-8f04-d57ed3122586"},
+		MsgPayload:  []byte(`{"name":"some message"}`),
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	msg := newMessage()
+
+	t.Run("sends signed request", func(t *testing.T) {
+		t.Parallel()
+
+		var received *http.Request
+		var body []byte
+		srv := httptest.NewServer(webhook.NewVerifier(secret).Middleware(
+			http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+			}),
+		))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, webhook.New(srv.URL, secret).Publish(context.Background(), msg))
+
+		require.NotNil(t, received)
+		require.Equal(t, http.MethodPost, received.Method)
+		require.Equal(t, msg.MsgPayload, body)
+		require.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		require.Equal(t, msg.MsgID, received.Header.Get(webhook.DefaultIdempotencyHeader))
+		require.Equal(t, msg.MsgMetadata["Aggregate-Id"], received.Header.Get("Aggregate-Id"))
+	})
+
+	t.Run("custom headers", func(t *testing.T) {
+		t.Parallel()
+
+		var received *http.Request
+		opts := []webhook.Option{
+			webhook.WithSignatureHeader("X-Signature"),
+			webhook.WithTimestampHeader("X-Timestamp"),
+		}
+		srv := httptest.NewServer(webhook.NewVerifier(secret, opts...).Middleware(
+			http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { received = r }),
+		))
+		t.Cleanup(srv.Close)
+
+		p := webhook.New(srv.URL, secret, append(opts,
+			webhook.WithMessageIDKey("X-Message-Id"),
+			webhook.WithContentType("text/plain"),
+		)...)
+		require.NoError(t, p.Publish(context.Background(), msg))
+
+		require.NotNil(t, received)
+		require.Equal(t, "text/plain", received.Header.Get("Content-Type"))
+		require.Equal(t, msg.MsgID, received.Header.Get("X-Message-Id"))
+	})
+
+	t.Run("resolves url", func(t *testing.T) {
+		t.Parallel()
+
+		var paths []string
+		srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+		}))
+		t.Cleanup(srv.Close)
+
+		m := newMessage()
+		m.MsgMetadata["endpoint"] = srv.URL + "/from-metadata"
+
+		require.NoError(t, webhook.New(srv.URL+"/default", secret).Publish(context.Background(), m))
+		require.NoError(t, webhook.New(srv.URL+"/default", secret, webhook.WithMetaURLKey("endpoint")).
+			Publish(context.Background(), m))
+		require.NoError(t, webhook.New("", secret, webhook.WithURLResolver(
+			func(_ context.Context, msg messenger.Message) (string, error) {
+				return srv.URL + "/" + msg.ID(), nil
+			},
+		)).Publish(context.Background(), m))
+
+		require.Equal(t, []string{"/default", "/from-metadata", "/" + m.MsgID}, paths)
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		t.Parallel()
+
+		require.ErrorIs(t, webhook.New("", secret).Publish(context.Background(), msg), webhook.ErrMissingURL)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			attempts.Add(1)
+		}))
+		t.Cleanup(srv.Close)
+
+		for _, md := range []map[string]string{
+			{"Aggregate Id": "1"},
+			{"Aggregate-Id": "1\r\nX-Injected: true"},
+		} {
+			m := newMessage()
+			m.MsgMetadata = md
+
+			err := webhook.New(srv.URL, secret, webhook.WithRetries(3, time.Millisecond)).Publish(context.Background(), m)
+			require.ErrorIs(t, err, webhook.ErrInvalidHeader)
+			require.True(t, messenger.IsPermanent(err))
+		}
+		require.Zero(t, attempts.Load())
+	})
+
+	t.Run("resolver fails", func(t *testing.T) {
+		t.Parallel()
+
+		errResolver := errors.New("unknown customer")
+		p := webhook.New("", secret, webhook.WithURLResolver(func(context.Context, messenger.Message) (string, error) {
+			return "", errResolver
+		}))
+
+		require.ErrorIs(t, p.Publish(context.Background(), msg), errResolver)
+	})
+
+	for _, tc := range []struct {
+		name      string
+		status    int
+		attempts  int32
+		permanent bool
+	}{
+		{name: "client error is permanent", status: http.StatusBadRequest, attempts: 1, permanent: true},
+		{name: "unauthorized is permanent", status: http.StatusUnauthorized, attempts: 1, permanent: true},
+		{name: "server error is retried", status: http.StatusInternalServerError, attempts: 3},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, attempts: 3},
+		{name: "timeout is retried", status: http.StatusRequestTimeout, attempts: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			err := webhook.New(srv.URL, secret, webhook.WithRetries(3, time.Millisecond)).
+				Publish(context.Background(), msg)
+
+			var statusErr *webhook.StatusError
+			require.ErrorAs(t, err, &statusErr)
+			require.Equal(t, tc.status, statusErr.StatusCode)
+			require.Equal(t, tc.permanent, messenger.IsPermanent(err))
+			require.Equal(t, tc.attempts, attempts.Load())
+		})
+	}
+
+	t.Run("retries until success", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, webhook.New(srv.URL, secret, webhook.WithRetries(5, time.Millisecond)).
+			Publish(context.Background(), msg))
+		require.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("stops retrying when context is done", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(srv.Close)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := webhook.New(srv.URL, secret, webhook.WithRetries(5, time.Minute)).Publish(ctx, msg)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.False(t, messenger.IsPermanent(err))
+	})
+}
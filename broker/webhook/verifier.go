@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTolerance   = 5 * time.Minute
+	defaultMaxBodySize = 1 << 20
+)
+
+// Errors returned when the request signature is not valid.
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+)
+
+// WithTolerance setups the max difference between the request timestamp and the current time,
+// older requests are rejected to prevent replays.
+func WithTolerance(d time.Duration) Option {
+	return func(c any) {
+		v, ok := c.(*Verifier)
+		if !ok {
+			return
+		}
+		v.tolerance = d
+	}
+}
+
+// WithMaxBodySize setups the max size in bytes of the request body read to verify the signature,
+// bigger requests are rejected with a *http.MaxBytesError.
+func WithMaxBodySize(n int64) Option {
+	return func(c any) {
+		v, ok := c.(*Verifier)
+		if !ok {
+			return
+		}
+		v.maxBodySize = n
+	}
+}
+
+// NewVerifier returns a new Verifier instance that checks the requests are signed with the given secret.
+func NewVerifier(secret []byte, opts ...Option) *Verifier {
+	v := Verifier{
+		secret:          secret,
+		tolerance:       defaultTolerance,
+		maxBodySize:     defaultMaxBodySize,
+		signatureHeader: DefaultSignatureHeader,
+		timestampHeader: DefaultTimestampHeader,
+	}
+
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	return &v
+}
+
+// Verifier checks the requests sent by a Publisher, so receivers only accept authentic
+// and recent webhooks.
+type Verifier struct {
+	secret      []byte
+	tolerance   time.Duration
+	maxBodySize int64
+
+	signatureHeader string
+	timestampHeader string
+}
+
+// Verify checks the request signature and timestamp, the request body is restored to be read again.
+// Bodies bigger than the max body size are not read and return a *http.MaxBytesError.
+func (v Verifier) Verify(r *http.Request) error {
+	signature := r.Header.Get(v.signatureHeader)
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(r.Header.Get(v.timestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTimestamp, err)
+	}
+	ts := time.Unix(unix, 0)
+	if diff := time.Since(ts); diff > v.tolerance || diff < -v.tolerance {
+		return fmt.Errorf("%w: outside tolerance", ErrInvalidTimestamp)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, v.maxBodySize))
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !hmac.Equal([]byte(signature), []byte(sign(v.secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Middleware returns a handler that responds with 401 Unauthorized to the requests not passing
+// the verification, or 413 Request Entity Too Large if the body exceeds the max body size,
+// the rest of requests are handled by the next handler.
+func (v Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			status := http.StatusUnauthorized
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, http.StatusText(status), status)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,155 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger/broker/webhook"
+)
+
+// signedRequest returns the request sent by a Publisher with the given secret.
+func signedRequest(t *testing.T, secret []byte) *http.Request {
+	t.Helper()
+
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req = httptest.NewRequest(r.Method, "/", strings.NewReader(string(body)))
+		req.Header = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	require.NoError(t, webhook.New(srv.URL, secret).Publish(context.Background(), newMessage()))
+
+	return req
+}
+
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		req         func(t *testing.T) *http.Request
+		opts        []webhook.Option
+		expectedErr error
+	}{
+		{
+			name: "valid signature",
+			req:  func(t *testing.T) *http.Request { return signedRequest(t, secret) },
+		},
+		{
+			name: "missing signature",
+			req: func(t *testing.T) *http.Request {
+				r := signedRequest(t, secret)
+				r.Header.Del(webhook.DefaultSignatureHeader)
+
+				return r
+			},
+			expectedErr: webhook.ErrMissingSignature,
+		},
+		{
+			name:        "different secret",
+			req:         func(t *testing.T) *http.Request { return signedRequest(t, []byte("other secret")) },
+			expectedErr: webhook.ErrInvalidSignature,
+		},
+		{
+			name: "modified body",
+			req: func(t *testing.T) *http.Request {
+				r := signedRequest(t, secret)
+				r.Body = io.NopCloser(strings.NewReader(`{"name":"modified"}`))
+
+				return r
+			},
+			expectedErr: webhook.ErrInvalidSignature,
+		},
+		{
+			name: "modified timestamp",
+			req: func(t *testing.T) *http.Request {
+				r := signedRequest(t, secret)
+				r.Header.Set(webhook.DefaultTimestampHeader, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+
+				return r
+			},
+			expectedErr: webhook.ErrInvalidSignature,
+		},
+		{
+			name: "invalid timestamp",
+			req: func(t *testing.T) *http.Request {
+				r := signedRequest(t, secret)
+				r.Header.Set(webhook.DefaultTimestampHeader, "yesterday")
+
+				return r
+			},
+			expectedErr: webhook.ErrInvalidTimestamp,
+		},
+		{
+			name: "expired timestamp",
+			req: func(t *testing.T) *http.Request {
+				r := signedRequest(t, secret)
+				r.Header.Set(webhook.DefaultTimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+
+				return r
+			},
+			opts:        []webhook.Option{webhook.WithTolerance(time.Minute)},
+			expectedErr: webhook.ErrInvalidTimestamp,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := tc.req(t)
+			err := webhook.NewVerifier(secret, tc.opts...).Verify(r)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+
+				return
+			}
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"name":"some message"}`, string(body))
+		})
+	}
+}
+
+func TestVerifierMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	var maxBytesErr *http.MaxBytesError
+	err := webhook.NewVerifier(secret, webhook.WithMaxBodySize(5)).Verify(signedRequest(t, secret))
+	require.ErrorAs(t, err, &maxBytesErr)
+	require.Equal(t, int64(5), maxBytesErr.Limit)
+}
+
+func TestVerifierMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var handled bool
+	h := webhook.NewVerifier(secret).Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		handled = true
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(t, []byte("other secret")))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.False(t, handled)
+
+	w = httptest.NewRecorder()
+	webhook.NewVerifier(secret, webhook.WithMaxBodySize(5)).Middleware(h).ServeHTTP(w, signedRequest(t, secret))
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.False(t, handled)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(t, secret))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, handled)
+}
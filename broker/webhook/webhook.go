@@ -0,0 +1,58 @@
+// Package webhook HTTP webhook broker implementation
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Default headers where the request signature, timestamp and message id are sent.
+const (
+	DefaultSignatureHeader   = "Webhook-Signature"
+	DefaultTimestampHeader   = "Webhook-Timestamp"
+	DefaultIdempotencyHeader = "Idempotency-Key"
+)
+
+// signaturePrefix identifies the algorithm used to sign the request.
+const signaturePrefix = "sha256="
+
+// Option is a function to set options to Publisher or Verifier.
+type Option func(any)
+
+// WithSignatureHeader modify default header where the signature is sent, used by Publisher and Verifier.
+func WithSignatureHeader(header string) Option {
+	return func(c any) {
+		switch v := c.(type) {
+		case *Publisher:
+			v.signatureHeader = header
+		case *Verifier:
+			v.signatureHeader = header
+		}
+	}
+}
+
+// WithTimestampHeader modify default header where the signature timestamp is sent, used by Publisher and Verifier.
+func WithTimestampHeader(header string) Option {
+	return func(c any) {
+		switch v := c.(type) {
+		case *Publisher:
+			v.timestampHeader = header
+		case *Verifier:
+			v.timestampHeader = header
+		}
+	}
+}
+
+// sign returns the signature of the payload sent at the given time,
+// signing the timestamp prevents replaying the request once the verifier tolerance has passed.
+func sign(secret []byte, ts time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,32 @@
+package messenger
+
+import "errors"
+
+// Permanent marks the given error as non retryable, so consumers and middlewares
+// do not retry the message processing, and Messenger does not publish the message again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether any error in err's tree is marked as non retryable.
+func IsPermanent(err error) bool {
+	var pErr *permanentError
+
+	return errors.As(err, &pErr)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
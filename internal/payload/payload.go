@@ -0,0 +1,75 @@
+// Package payload embeds message payloads in JSON documents, so they are recovered byte by byte.
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encodings of the payloads embedded in JSON documents.
+const (
+	// EncodingJSON is the encoding of compact JSON payloads, embedded as they are.
+	EncodingJSON = "json"
+	// EncodingBase64 is the encoding of any other payload, embedded as a base64 string.
+	EncodingBase64 = "base64"
+)
+
+// ErrUnknownEncoding is returned when a payload encoding is not supported.
+var ErrUnknownEncoding = errors.New("unknown payload encoding")
+
+// Encode returns the JSON value to embed the payload and its encoding.
+func Encode(payload []byte) (string, json.RawMessage, error) {
+	if isCompactJSON(payload) {
+		return EncodingJSON, payload, nil
+	}
+
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return EncodingBase64, value, nil
+}
+
+// Decode restores the payload embedded as the given JSON value with the given encoding.
+func Decode(encoding string, value json.RawMessage) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		return value, nil
+	case EncodingBase64:
+		var payload []byte
+		if err := json.Unmarshal(value, &payload); err != nil {
+			return nil, err
+		}
+
+		return payload, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncoding, encoding)
+	}
+}
+
+// Marshal returns the JSON encoding of v without the trailing new line. HTML characters
+// are not escaped so the embedded payloads are kept byte by byte.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// isCompactJSON reports whether the payload is valid JSON without insignificant whitespace,
+// so it is not modified when embedded in a JSON document.
+func isCompactJSON(payload []byte) bool {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, payload); err != nil {
+		return false
+	}
+
+	return bytes.Equal(buf.Bytes(), payload)
+}
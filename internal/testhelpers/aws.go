@@ -10,7 +10,8 @@ import (
 	"github.com/testcontainers/testcontainers-go/modules/localstack"
 )
 
-// CreateLocalStackContainer starts a local stack container with SNS and SQS services, and returns its instance.
+// CreateLocalStackContainer starts a local stack container with SNS, SQS, EventBridge and Kinesis services,
+// and returns its instance.
 func CreateLocalStackContainer(
 	ctx context.Context,
 ) (aws.Config, *localstack.LocalStackContainer, error) {
@@ -18,7 +19,7 @@ func CreateLocalStackContainer(
 		"localstack/localstack:4.7.0",
 		testcontainers.CustomizeRequest(testcontainers.GenericContainerRequest{
 			ContainerRequest: testcontainers.ContainerRequest{
-				Env: map[string]string{"SERVICES": "sns,sqs"},
+				Env: map[string]string{"SERVICES": "sns,sqs,events,kinesis"},
 			},
 		}),
 	)
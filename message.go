@@ -3,9 +3,11 @@ package messenger
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/x4b1/messenger/internal/payload"
 )
 
 // ErrEmptyMessagePayload is the error returned when the message payload is empty.
@@ -25,7 +27,10 @@ func NewMessage(payload []byte) (*GenericMessage, error) {
 	}, nil
 }
 
-var _ json.Marshaler = (*GenericMessage)(nil)
+var (
+	_ json.Marshaler   = (*GenericMessage)(nil)
+	_ json.Unmarshaler = (*GenericMessage)(nil)
+)
 
 // A Message represents a message to be sent to message message queue.
 type Message interface {
@@ -51,27 +56,59 @@ type GenericMessage struct {
 	MsgAt time.Time
 }
 
-// MarshalJSON implements json.Marshaler.
+// genericMessageJSON is the JSON encoding of GenericMessage.
+type genericMessageJSON struct {
+	ID              string          `json:"id"`
+	Metadata        Metadata        `json:"metadata"`
+	PayloadEncoding string          `json:"payload_encoding"`
+	Payload         json.RawMessage `json:"payload"`
+	Published       bool            `json:"published"`
+	At              time.Time       `json:"at"`
+}
+
+// MarshalJSON implements json.Marshaler. The payload_encoding field tells how the payload is encoded
+// so UnmarshalJSON restores the exact bytes: "json" when the payload is compact JSON embedded as it is,
+// otherwise "base64" with the payload as a base64 string. HTML characters are not escaped,
+// json.Marshal escapes them in the embedded payload, call MarshalJSON to keep it byte by byte.
 func (m *GenericMessage) MarshalJSON() ([]byte, error) {
-	var payload any = string(m.MsgPayload)
-	if json.Valid(m.MsgPayload) {
-		payload = json.RawMessage(m.MsgPayload)
+	encoding, value, err := payload.Encode(m.MsgPayload)
+	if err != nil {
+		return nil, err
 	}
-	return json.Marshal(struct {
-		ID        string    `json:"id"`
-		Metadata  Metadata  `json:"metadata"`
-		Payload   any       `json:"payload"`
-		Published bool      `json:"published"`
-		At        time.Time `json:"at"`
-	}{
-		ID:        m.MsgID,
-		Metadata:  m.MsgMetadata,
-		Payload:   payload,
-		Published: m.MsgPublished,
-		At:        m.MsgAt,
+
+	return payload.Marshal(genericMessageJSON{
+		ID:              m.MsgID,
+		Metadata:        m.MsgMetadata,
+		PayloadEncoding: encoding,
+		Payload:         value,
+		Published:       m.MsgPublished,
+		At:              m.MsgAt,
 	})
 }
 
+// UnmarshalJSON implements json.Unmarshaler, restoring the message encoded by MarshalJSON.
+func (m *GenericMessage) UnmarshalJSON(b []byte) error {
+	var msg genericMessageJSON
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+
+	p, err := payload.Decode(msg.PayloadEncoding, msg.Payload)
+	if err != nil {
+		return fmt.Errorf("decoding payload: %w", err)
+	}
+
+	*m = GenericMessage{
+		MsgID:        msg.ID,
+		MsgMetadata:  msg.Metadata,
+		MsgPayload:   p,
+		MsgPublished: msg.Published,
+		MsgAt:        msg.At,
+	}
+
+	return nil
+}
+
 // ID returns the unique identifier of the message.
 func (m *GenericMessage) ID() string {
 	return m.MsgID
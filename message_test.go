@@ -1,6 +1,7 @@
 package messenger_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 	"time"
@@ -52,7 +53,8 @@ func TestGenericMessage_MarshalJSON(t *testing.T) {
 		require.JSONEq(t, `{
 			"id":"`+msg.ID()+`",
 			"metadata":{},
-			"payload":"`+somePayload+`",
+			"payload_encoding":"base64",
+			"payload":"`+base64.StdEncoding.EncodeToString([]byte(somePayload))+`",
 			"published":false,
 			"at":"`+msg.At().Format(time.RFC3339Nano)+`"
 		}`, string(b))
@@ -69,9 +71,53 @@ func TestGenericMessage_MarshalJSON(t *testing.T) {
 		require.JSONEq(t, `{
 		"id":"`+msg.ID()+`",
 		"metadata":{},
+		"payload_encoding":"json",
 		"payload":`+somePayload+`,
 		"published":false,
 		"at":"`+msg.At().Format(time.RFC3339Nano)+`"
 	}`, string(b))
 	})
+
+	t.Run("keeps html characters of the payload", func(t *testing.T) {
+		msg, err := messenger.NewMessage([]byte(`{"name":"<some> & message"}`))
+		require.NoError(t, err)
+
+		b, err := msg.MarshalJSON()
+		require.NoError(t, err)
+		require.Contains(t, string(b), `"payload_encoding":"json","payload":{"name":"<some> & message"}`)
+	})
+}
+
+func TestGenericMessage_UnmarshalJSON(t *testing.T) {
+	for name, payload := range map[string]string{
+		"compact json payload":     `{"hello":"world"}`,
+		"not compact json payload": `{ "hello": "world" }`,
+		"html json payload":        `{"name":"<some> & message"}`,
+		"raw string payload":       "hello world",
+		"binary payload":           "\xff\x00\xfe",
+	} {
+		t.Run(name, func(t *testing.T) {
+			msg, err := messenger.NewMessage([]byte(payload))
+			require.NoError(t, err)
+			msg.SetMetadata("key", "value")
+			msg.MsgPublished = true
+
+			b, err := msg.MarshalJSON()
+			require.NoError(t, err)
+
+			var decoded messenger.GenericMessage
+			require.NoError(t, json.Unmarshal(b, &decoded))
+			require.Equal(t, msg.ID(), decoded.ID())
+			require.Equal(t, msg.Metadata(), decoded.Metadata())
+			require.Equal(t, msg.Payload(), decoded.Payload())
+			require.Equal(t, msg.Published(), decoded.Published())
+			require.True(t, msg.At().Equal(decoded.At()))
+		})
+	}
+
+	t.Run("unknown payload encoding", func(t *testing.T) {
+		var msg messenger.GenericMessage
+		err := json.Unmarshal([]byte(`{"id":"1","metadata":{},"payload_encoding":"hex","payload":"61"}`), &msg)
+		require.ErrorContains(t, err, "unknown payload encoding")
+	})
 }
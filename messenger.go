@@ -5,6 +5,8 @@ package messenger
 import (
 	"context"
 	"errors"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/x4b1/messenger/log"
@@ -14,7 +16,7 @@ const (
 	defaultBatchSize = 100
 )
 
-//go:generate go tool moq -stub -pkg messenger_test -out mock_test.go . Store Publisher ErrorHandler
+//go:generate go tool moq -stub -pkg messenger_test -out mock_test.go . Store Publisher BatchPublisher ErrorHandler Subscriber
 
 // Store is the interface that wraps the message retrieval and update methods.
 type Store interface {
@@ -32,6 +34,35 @@ type Publisher interface {
 	Publish(ctx context.Context, msg Message) error
 }
 
+// BatchPublisher is the interface implemented by publishers able to send several messages at once,
+// Messenger publishes every batch of messages with PublishBatch when the publisher implements it.
+type BatchPublisher interface {
+	Publisher
+	// Sends the messages to broker. If only some of them fail it returns a *BatchError,
+	// any other error means that none of the messages was sent.
+	PublishBatch(ctx context.Context, msgs []Message) error
+}
+
+// BatchError is returned by BatchPublisher when some of the messages fail to be sent.
+type BatchError struct {
+	// Errors of the failed messages by message id.
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+// Unwrap returns the errors of the failed messages sorted by message id.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, id := range slices.Sorted(maps.Keys(e.Errors)) {
+		errs = append(errs, e.Errors[id])
+	}
+
+	return errs
+}
+
 // ErrorHandler is the interface that wraps the basic message publishing.
 type ErrorHandler interface {
 	Error(ctx context.Context, err error)
@@ -116,18 +147,26 @@ type Messenger struct {
 	publisher  Publisher
 }
 
-// Publish runs once publishing process.
+// Publish runs once publishing process. Messages failing with a permanent error, see Permanent,
+// are marked as published along with the sent ones, as publishing them again would fail too,
+// the error is still returned so it is reported.
 func (w *Messenger) Publish(ctx context.Context) error {
 	msgs, err := w.store.Messages(ctx, w.batchSize)
 	if err != nil {
 		return &fatalError{err}
 	}
 
+	if p, ok := w.publisher.(BatchPublisher); ok && len(msgs) > 0 {
+		return w.publishBatch(ctx, p, msgs)
+	}
+
 	errs := []error{}
 	for _, msg := range msgs {
 		if err := w.publisher.Publish(ctx, msg); err != nil {
 			errs = append(errs, err)
-			continue
+			if !IsPermanent(err) {
+				continue
+			}
 		}
 		if err := w.store.Published(ctx, msg); err != nil {
 			errs = append(errs, err)
@@ -138,6 +177,33 @@ func (w *Messenger) Publish(ctx context.Context) error {
 	return errors.Join(errs...)
 }
 
+// publishBatch sends all the messages at once, marking as published the ones sent successfully
+// or failing with a permanent error.
+func (w *Messenger) publishBatch(ctx context.Context, p BatchPublisher, msgs []Message) error {
+	errs := []error{}
+
+	var batchErr *BatchError
+	if err := p.PublishBatch(ctx, msgs); err != nil {
+		if !errors.As(err, &batchErr) && !IsPermanent(err) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+
+	for _, msg := range msgs {
+		if batchErr != nil {
+			if err, failed := batchErr.Errors[msg.ID()]; failed && !IsPermanent(err) {
+				continue
+			}
+		}
+		if err := w.store.Published(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // Clean runs once the message cleaning process given a message expiration time.
 func (w *Messenger) Clean(ctx context.Context) error {
 	return w.store.DeletePublishedByExpiration(ctx, w.expiration)
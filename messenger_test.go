@@ -77,6 +77,104 @@ func (s *publisherSuite) TestPublishMessages() {
 	}
 }
 
+func (s *publisherSuite) TestPublishBatchMessages() {
+	s.sourceMock.MessagesFunc = func(context.Context, int) ([]messenger.Message, error) {
+		return s.messages, nil
+	}
+
+	publisherror := errors.New("publishing error")
+	batchMock := &BatchPublisherMock{
+		PublishBatchFunc: func(context.Context, []messenger.Message) error {
+			return &messenger.BatchError{Errors: map[string]error{s.messages[1].ID(): publisherror}}
+		},
+	}
+	publisher := messenger.NewMessenger(s.sourceMock, batchMock, messenger.WithPublishBatchSize(s.batchSize))
+
+	err := publisher.Publish(context.Background())
+	s.Require().ErrorIs(err, publisherror)
+
+	s.Len(batchMock.PublishBatchCalls(), 1)
+	s.Equal(s.messages, batchMock.PublishBatchCalls()[0].Msgs)
+	s.Empty(batchMock.PublishCalls())
+
+	s.Len(s.sourceMock.PublishedCalls(), 2)
+	for i, c := range []messenger.Message{s.messages[0], s.messages[2]} {
+		s.Equal(c, s.sourceMock.PublishedCalls()[i].Msg)
+	}
+}
+
+func (s *publisherSuite) TestPublishBatchFails() {
+	s.sourceMock.MessagesFunc = func(context.Context, int) ([]messenger.Message, error) {
+		return s.messages, nil
+	}
+
+	publisherror := errors.New("publishing error")
+	batchMock := &BatchPublisherMock{
+		PublishBatchFunc: func(context.Context, []messenger.Message) error {
+			return publisherror
+		},
+	}
+	publisher := messenger.NewMessenger(s.sourceMock, batchMock)
+
+	s.Require().ErrorIs(publisher.Publish(context.Background()), publisherror)
+	s.Empty(s.sourceMock.PublishedCalls())
+}
+
+func (s *publisherSuite) TestPublishPermanentErrorsMarksPublished() {
+	s.sourceMock.MessagesFunc = func(context.Context, int) ([]messenger.Message, error) {
+		return s.messages, nil
+	}
+
+	permanentErr := messenger.Permanent(errors.New("invalid message"))
+	transientErr := errors.New("publishing error")
+	s.publishMock.PublishFunc = func(_ context.Context, msg messenger.Message) error {
+		switch msg.ID() {
+		case s.messages[0].ID():
+			return permanentErr
+		case s.messages[1].ID():
+			return transientErr
+		default:
+			return nil
+		}
+	}
+
+	err := s.publisher.Publish(context.Background())
+	s.Require().ErrorIs(err, permanentErr)
+	s.Require().ErrorIs(err, transientErr)
+
+	s.Len(s.sourceMock.PublishedCalls(), 2)
+	for i, c := range []messenger.Message{s.messages[0], s.messages[2]} {
+		s.Equal(c, s.sourceMock.PublishedCalls()[i].Msg)
+	}
+}
+
+func (s *publisherSuite) TestPublishBatchPermanentErrorsMarksPublished() {
+	s.sourceMock.MessagesFunc = func(context.Context, int) ([]messenger.Message, error) {
+		return s.messages, nil
+	}
+
+	permanentErr := messenger.Permanent(errors.New("invalid message"))
+	transientErr := errors.New("publishing error")
+	batchMock := &BatchPublisherMock{
+		PublishBatchFunc: func(context.Context, []messenger.Message) error {
+			return &messenger.BatchError{Errors: map[string]error{
+				s.messages[0].ID(): permanentErr,
+				s.messages[1].ID(): transientErr,
+			}}
+		},
+	}
+	publisher := messenger.NewMessenger(s.sourceMock, batchMock)
+
+	err := publisher.Publish(context.Background())
+	s.Require().ErrorIs(err, permanentErr)
+	s.Require().ErrorIs(err, transientErr)
+
+	s.Len(s.sourceMock.PublishedCalls(), 2)
+	for i, c := range []messenger.Message{s.messages[0], s.messages[2]} {
+		s.Equal(c, s.sourceMock.PublishedCalls()[i].Msg)
+	}
+}
+
 func (s *publisherSuite) TestFailsGettingMessages() {
 	gettingMessagesErr := errors.New("getting messages")
 	s.sourceMock.MessagesFunc = func(context.Context, int) ([]messenger.Message, error) {
@@ -0,0 +1,114 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// ErrHandlerPanic is the error returned when a subscription handler panics.
+var ErrHandlerPanic = errors.New("subscription handler panic")
+
+// maxRetryBackoff is the limit of the Retry middleware exponential backoff.
+const maxRetryBackoff = time.Minute
+
+// Recoverer returns a middleware that recovers from panics in the handler
+// and reports them as an error wrapping ErrHandlerPanic.
+func Recoverer() SubscriptionMiddleware {
+	return func(next SubscriptionHandler) SubscriptionHandler {
+		return func(ctx context.Context, msg Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%w: %v\n%s", ErrHandlerPanic, r, debug.Stack())
+				}
+			}()
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Timeout returns a middleware that cancels the handler context once the given duration is exceeded.
+func Timeout(d time.Duration) SubscriptionMiddleware {
+	return func(next SubscriptionHandler) SubscriptionHandler {
+		return func(ctx context.Context, msg Message) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// Retry returns a middleware that retries the handler in process until it succeeds,
+// it fails with a permanent error or the max attempts are reached, the handler is called at least once.
+// Between attempts it waits an exponential backoff starting with the given duration, doubling it up to
+// a minute, or the given duration if greater. It returns the last handler error.
+func Retry(maxAttempts int, backoff time.Duration) SubscriptionMiddleware {
+	maxAttempts = max(maxAttempts, 1)
+
+	return func(next SubscriptionHandler) SubscriptionHandler {
+		return func(ctx context.Context, msg Message) error {
+			var err error
+			for attempt := range maxAttempts {
+				if attempt > 0 {
+					t := time.NewTimer(retryBackoff(backoff, attempt))
+					select {
+					case <-ctx.Done():
+						t.Stop()
+
+						return errors.Join(err, ctx.Err())
+					case <-t.C:
+					}
+				}
+
+				if err = next(ctx, msg); err == nil || IsPermanent(err) {
+					return err
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+// retryBackoff returns the time to wait before the given attempt, the first retry is attempt 1.
+func retryBackoff(backoff time.Duration, attempt int) time.Duration {
+	limit := max(backoff, maxRetryBackoff)
+	for range attempt - 1 {
+		if backoff > limit/2 {
+			return limit
+		}
+		backoff *= 2
+	}
+
+	return backoff
+}
+
+// Logger returns a middleware that logs every handled message with its identifier, metadata,
+// the time it took and the error if the handler fails.
+func Logger(l *slog.Logger) SubscriptionMiddleware {
+	return func(next SubscriptionHandler) SubscriptionHandler {
+		return func(ctx context.Context, msg Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			attrs := []slog.Attr{
+				slog.String("message_id", msg.ID()),
+				slog.Any("metadata", msg.Metadata()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				l.LogAttrs(ctx, slog.LevelError, "message handling failed", append(attrs, slog.Any("error", err))...)
+
+				return err
+			}
+			l.LogAttrs(ctx, slog.LevelInfo, "message handled", attrs...)
+
+			return nil
+		}
+	}
+}
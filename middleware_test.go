@@ -0,0 +1,149 @@
+package messenger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+)
+
+func TestSubscriptionMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	msg := &messenger.GenericMessage{
+		MsgID:       "0a0d3d3e-5f4b-4bb8-9c1b-0c2b0e0cd1e4",
+		MsgMetadata: messenger.Metadata{"type": "created"},
+		MsgPayload:  []byte("hello"),
+	}
+
+	t.Run("applies middlewares in order", func(t *testing.T) {
+		t.Parallel()
+
+		calls := []string{}
+		mw := func(name string) messenger.SubscriptionMiddleware {
+			return func(next messenger.SubscriptionHandler) messenger.SubscriptionHandler {
+				return func(ctx context.Context, msg messenger.Message) error {
+					calls = append(calls, name)
+					return next(ctx, msg)
+				}
+			}
+		}
+
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			calls = append(calls, "handler")
+			return nil
+		}, mw("first"), mw("second"))
+
+		sub = messenger.WithMiddleware(sub, mw("wrapper"))
+
+		require.Equal(t, "test", sub.Name())
+		require.NoError(t, sub.Handle(context.Background(), msg))
+		require.Equal(t, []string{"wrapper", "first", "second", "handler"}, calls)
+	})
+
+	t.Run("recoverer returns panic as error", func(t *testing.T) {
+		t.Parallel()
+
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			panic("boom")
+		}, messenger.Recoverer())
+
+		err := sub.Handle(context.Background(), msg)
+		require.ErrorIs(t, err, messenger.ErrHandlerPanic)
+		require.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("timeout cancels handler context", func(t *testing.T) {
+		t.Parallel()
+
+		sub := messenger.NewSubscription("test", func(ctx context.Context, _ messenger.Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, messenger.Timeout(10*time.Millisecond))
+
+		require.ErrorIs(t, sub.Handle(context.Background(), msg), context.DeadlineExceeded)
+	})
+
+	t.Run("retry until success", func(t *testing.T) {
+		t.Parallel()
+
+		attempts := 0
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("handler error")
+			}
+			return nil
+		}, messenger.Retry(3, time.Millisecond))
+
+		require.NoError(t, sub.Handle(context.Background(), msg))
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("retry returns last error", func(t *testing.T) {
+		t.Parallel()
+
+		handlerErr := errors.New("handler error")
+		attempts := 0
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			attempts++
+			return handlerErr
+		}, messenger.Retry(2, time.Millisecond))
+
+		require.ErrorIs(t, sub.Handle(context.Background(), msg), handlerErr)
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("retry calls the handler at least once", func(t *testing.T) {
+		t.Parallel()
+
+		for _, maxAttempts := range []int{-1, 0, 1} {
+			handlerErr := errors.New("handler error")
+			attempts := 0
+			sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+				attempts++
+				return handlerErr
+			}, messenger.Retry(maxAttempts, time.Minute))
+
+			require.ErrorIs(t, sub.Handle(context.Background(), msg), handlerErr)
+			require.Equal(t, 1, attempts)
+		}
+	})
+
+	t.Run("retry stops when context is done", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		handlerErr := errors.New("handler error")
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			cancel()
+			return handlerErr
+		}, messenger.Retry(5, time.Minute))
+
+		err := sub.Handle(ctx, msg)
+		require.ErrorIs(t, err, handlerErr)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("logger logs message details", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handlerErr := errors.New("handler error")
+		sub := messenger.NewSubscription("test", func(context.Context, messenger.Message) error {
+			return handlerErr
+		}, messenger.Logger(l))
+
+		require.ErrorIs(t, sub.Handle(context.Background(), msg), handlerErr)
+		require.Contains(t, buf.String(), `"message_id":"`+msg.MsgID+`"`)
+		require.Contains(t, buf.String(), `"metadata":{"type":"created"}`)
+		require.Contains(t, buf.String(), `"error":"handler error"`)
+	})
+}
@@ -267,6 +267,134 @@ func (mock *PublisherMock) PublishCalls() []struct {
 	return calls
 }
 
+// Ensure, that BatchPublisherMock does implement messenger.BatchPublisher.
+// If this is not the case, regenerate this file with moq.
+var _ messenger.BatchPublisher = &BatchPublisherMock{}
+
+// BatchPublisherMock is a mock implementation of messenger.BatchPublisher.
+//
+//	func TestSomethingThatUsesBatchPublisher(t *testing.T) {
+//
+//		// make and configure a mocked messenger.BatchPublisher
+//		mockedBatchPublisher := &BatchPublisherMock{
+//			PublishFunc: func(ctx context.Context, msg messenger.Message) error {
+//				panic("mock out the Publish method")
+//			},
+//			PublishBatchFunc: func(ctx context.Context, msgs []messenger.Message) error {
+//				panic("mock out the PublishBatch method")
+//			},
+//		}
+//
+//		// use mockedBatchPublisher in code that requires messenger.BatchPublisher
+//		// and then make assertions.
+//
+//	}
+type BatchPublisherMock struct {
+	// PublishFunc mocks the Publish method.
+	PublishFunc func(ctx context.Context, msg messenger.Message) error
+
+	// PublishBatchFunc mocks the PublishBatch method.
+	PublishBatchFunc func(ctx context.Context, msgs []messenger.Message) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Publish holds details about calls to the Publish method.
+		Publish []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Msg is the msg argument value.
+			Msg messenger.Message
+		}
+		// PublishBatch holds details about calls to the PublishBatch method.
+		PublishBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Msgs is the msgs argument value.
+			Msgs []messenger.Message
+		}
+	}
+	lockPublish      sync.RWMutex
+	lockPublishBatch sync.RWMutex
+}
+
+// Publish calls PublishFunc.
+func (mock *BatchPublisherMock) Publish(ctx context.Context, msg messenger.Message) error {
+	callInfo := struct {
+		Ctx context.Context
+		Msg messenger.Message
+	}{
+		Ctx: ctx,
+		Msg: msg,
+	}
+	mock.lockPublish.Lock()
+	mock.calls.Publish = append(mock.calls.Publish, callInfo)
+	mock.lockPublish.Unlock()
+	if mock.PublishFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.PublishFunc(ctx, msg)
+}
+
+// PublishCalls gets all the calls that were made to Publish.
+// Check the length with:
+//
+//	len(mockedBatchPublisher.PublishCalls())
+func (mock *BatchPublisherMock) PublishCalls() []struct {
+	Ctx context.Context
+	Msg messenger.Message
+} {
+	var calls []struct {
+		Ctx context.Context
+		Msg messenger.Message
+	}
+	mock.lockPublish.RLock()
+	calls = mock.calls.Publish
+	mock.lockPublish.RUnlock()
+	return calls
+}
+
+// PublishBatch calls PublishBatchFunc.
+func (mock *BatchPublisherMock) PublishBatch(ctx context.Context, msgs []messenger.Message) error {
+	callInfo := struct {
+		Ctx  context.Context
+		Msgs []messenger.Message
+	}{
+		Ctx:  ctx,
+		Msgs: msgs,
+	}
+	mock.lockPublishBatch.Lock()
+	mock.calls.PublishBatch = append(mock.calls.PublishBatch, callInfo)
+	mock.lockPublishBatch.Unlock()
+	if mock.PublishBatchFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.PublishBatchFunc(ctx, msgs)
+}
+
+// PublishBatchCalls gets all the calls that were made to PublishBatch.
+// Check the length with:
+//
+//	len(mockedBatchPublisher.PublishBatchCalls())
+func (mock *BatchPublisherMock) PublishBatchCalls() []struct {
+	Ctx  context.Context
+	Msgs []messenger.Message
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Msgs []messenger.Message
+	}
+	mock.lockPublishBatch.RLock()
+	calls = mock.calls.PublishBatch
+	mock.lockPublishBatch.RUnlock()
+	return calls
+}
+
 // Ensure, that ErrorHandlerMock does implement messenger.ErrorHandler.
 // If this is not the case, regenerate this file with moq.
 var _ messenger.ErrorHandler = &ErrorHandlerMock{}
@@ -338,3 +466,163 @@ func (mock *ErrorHandlerMock) ErrorCalls() []struct {
 	mock.lockError.RUnlock()
 	return calls
 }
+
+// Ensure, that SubscriberMock does implement messenger.Subscriber.
+// If this is not the case, regenerate this file with moq.
+var _ messenger.Subscriber = &SubscriberMock{}
+
+// SubscriberMock is a mock implementation of messenger.Subscriber.
+//
+//	func TestSomethingThatUsesSubscriber(t *testing.T) {
+//
+//		// make and configure a mocked messenger.Subscriber
+//		mockedSubscriber := &SubscriberMock{
+//			CloseFunc: func(ctx context.Context) error {
+//				panic("mock out the Close method")
+//			},
+//			ListenFunc: func(ctx context.Context) error {
+//				panic("mock out the Listen method")
+//			},
+//			RegisterFunc: func(subs ...messenger.Subscription)  {
+//				panic("mock out the Register method")
+//			},
+//		}
+//
+//		// use mockedSubscriber in code that requires messenger.Subscriber
+//		// and then make assertions.
+//
+//	}
+type SubscriberMock struct {
+	// CloseFunc mocks the Close method.
+	CloseFunc func(ctx context.Context) error
+
+	// ListenFunc mocks the Listen method.
+	ListenFunc func(ctx context.Context) error
+
+	// RegisterFunc mocks the Register method.
+	RegisterFunc func(subs ...messenger.Subscription)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Close holds details about calls to the Close method.
+		Close []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Listen holds details about calls to the Listen method.
+		Listen []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Register holds details about calls to the Register method.
+		Register []struct {
+			// Subs is the subs argument value.
+			Subs []messenger.Subscription
+		}
+	}
+	lockClose    sync.RWMutex
+	lockListen   sync.RWMutex
+	lockRegister sync.RWMutex
+}
+
+// Close calls CloseFunc.
+func (mock *SubscriberMock) Close(ctx context.Context) error {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockClose.Lock()
+	mock.calls.Close = append(mock.calls.Close, callInfo)
+	mock.lockClose.Unlock()
+	if mock.CloseFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CloseFunc(ctx)
+}
+
+// CloseCalls gets all the calls that were made to Close.
+// Check the length with:
+//
+//	len(mockedSubscriber.CloseCalls())
+func (mock *SubscriberMock) CloseCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockClose.RLock()
+	calls = mock.calls.Close
+	mock.lockClose.RUnlock()
+	return calls
+}
+
+// Listen calls ListenFunc.
+func (mock *SubscriberMock) Listen(ctx context.Context) error {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListen.Lock()
+	mock.calls.Listen = append(mock.calls.Listen, callInfo)
+	mock.lockListen.Unlock()
+	if mock.ListenFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.ListenFunc(ctx)
+}
+
+// ListenCalls gets all the calls that were made to Listen.
+// Check the length with:
+//
+//	len(mockedSubscriber.ListenCalls())
+func (mock *SubscriberMock) ListenCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListen.RLock()
+	calls = mock.calls.Listen
+	mock.lockListen.RUnlock()
+	return calls
+}
+
+// Register calls RegisterFunc.
+func (mock *SubscriberMock) Register(subs ...messenger.Subscription) {
+	callInfo := struct {
+		Subs []messenger.Subscription
+	}{
+		Subs: subs,
+	}
+	mock.lockRegister.Lock()
+	mock.calls.Register = append(mock.calls.Register, callInfo)
+	mock.lockRegister.Unlock()
+	if mock.RegisterFunc == nil {
+		return
+	}
+	mock.RegisterFunc(subs...)
+}
+
+// RegisterCalls gets all the calls that were made to Register.
+// Check the length with:
+//
+//	len(mockedSubscriber.RegisterCalls())
+func (mock *SubscriberMock) RegisterCalls() []struct {
+	Subs []messenger.Subscription
+} {
+	var calls []struct {
+		Subs []messenger.Subscription
+	}
+	mock.lockRegister.RLock()
+	calls = mock.calls.Register
+	mock.lockRegister.RUnlock()
+	return calls
+}
@@ -0,0 +1,94 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownMessageType is the error returned when the router does not have a handler for a message.
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+var _ Subscription = &Router{}
+
+// RouterOption defines the optional parameters for Router.
+type RouterOption func(*Router)
+
+// WithUnknownAck acknowledges the messages without a matching route, dropping them.
+func WithUnknownAck() RouterOption {
+	return func(r *Router) {
+		r.unknown = func(context.Context, Message) error {
+			return nil
+		}
+	}
+}
+
+// WithUnknownNack returns an error wrapping ErrUnknownMessageType for the messages without a matching route,
+// so the broker delivers them again. This is the default behaviour.
+func WithUnknownNack() RouterOption {
+	return func(r *Router) {
+		r.unknown = r.nack
+	}
+}
+
+// WithUnknownDeadLetter publishes the messages without a matching route to the given publisher,
+// acknowledging them once they are published.
+func WithUnknownDeadLetter(p Publisher) RouterOption {
+	return func(r *Router) {
+		r.unknown = func(ctx context.Context, msg Message) error {
+			if err := p.Publish(ctx, msg); err != nil {
+				return fmt.Errorf("publishing unknown message to dead letter: %w", err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// NewRouter returns a Router subscription that dispatches the messages depending
+// on the value of the given metadata key.
+// By default messages without a matching route are not acknowledged.
+func NewRouter(name, key string, opts ...RouterOption) *Router {
+	r := Router{
+		key:    key,
+		routes: map[string]SubscriptionHandler{},
+	}
+	r.unknown = r.nack
+	r.Subscription = NewSubscription(name, r.dispatch)
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return &r
+}
+
+// Router is a subscription that contains multiple handlers and a metadata key to allow route messages
+// depending on the metadata value of the message.
+type Router struct {
+	Subscription
+
+	key     string
+	routes  map[string]SubscriptionHandler
+	unknown SubscriptionHandler
+}
+
+// AddRoute registers the handler with the value filter.
+// The given middlewares wrap only this handler.
+func (r *Router) AddRoute(value string, h SubscriptionHandler, mws ...SubscriptionMiddleware) {
+	r.routes[value] = chain(h, mws...)
+}
+
+// dispatch routes the message to a handler depending if it matches the metadata key and metadata value.
+func (r *Router) dispatch(ctx context.Context, msg Message) error {
+	h, ok := r.routes[msg.Metadata().Get(r.key)]
+	if !ok {
+		return r.unknown(ctx, msg)
+	}
+
+	return h(ctx, msg)
+}
+
+func (r *Router) nack(_ context.Context, msg Message) error {
+	return fmt.Errorf("%w: %s=%q", ErrUnknownMessageType, r.key, msg.Metadata().Get(r.key))
+}
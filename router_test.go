@@ -0,0 +1,118 @@
+package messenger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	const typeKey = "type"
+
+	newMsg := func(t *testing.T, eventType string) *messenger.GenericMessage {
+		t.Helper()
+
+		msg, err := messenger.NewMessage([]byte("{}"))
+		require.NoError(t, err)
+		msg.SetMetadata(typeKey, eventType)
+
+		return msg
+	}
+
+	t.Run("dispatches by metadata value", func(t *testing.T) {
+		t.Parallel()
+
+		calls := []string{}
+		r := messenger.NewRouter("orders", typeKey)
+		r.AddRoute("order.created", func(context.Context, messenger.Message) error {
+			calls = append(calls, "created")
+			return nil
+		})
+		r.AddRoute("order.deleted", func(context.Context, messenger.Message) error {
+			calls = append(calls, "deleted")
+			return nil
+		})
+
+		require.Equal(t, "orders", r.Name())
+		require.NoError(t, r.Handle(context.Background(), newMsg(t, "order.deleted")))
+		require.NoError(t, r.Handle(context.Background(), newMsg(t, "order.created")))
+		require.Equal(t, []string{"deleted", "created"}, calls)
+	})
+
+	t.Run("returns handler error", func(t *testing.T) {
+		t.Parallel()
+
+		handlerErr := errors.New("handler error")
+		r := messenger.NewRouter("orders", typeKey)
+		r.AddRoute("order.created", func(context.Context, messenger.Message) error {
+			return handlerErr
+		})
+
+		require.ErrorIs(t, r.Handle(context.Background(), newMsg(t, "order.created")), handlerErr)
+	})
+
+	t.Run("unknown type nacks by default", func(t *testing.T) {
+		t.Parallel()
+
+		err := messenger.NewRouter("orders", typeKey).Handle(context.Background(), newMsg(t, "order.updated"))
+		require.ErrorIs(t, err, messenger.ErrUnknownMessageType)
+	})
+
+	t.Run("unknown type acks", func(t *testing.T) {
+		t.Parallel()
+
+		r := messenger.NewRouter("orders", typeKey, messenger.WithUnknownAck())
+		require.NoError(t, r.Handle(context.Background(), newMsg(t, "order.updated")))
+	})
+
+	t.Run("unknown type dead letters", func(t *testing.T) {
+		t.Parallel()
+
+		dlq := &PublisherMock{}
+		r := messenger.NewRouter("orders", typeKey, messenger.WithUnknownDeadLetter(dlq))
+
+		msg := newMsg(t, "order.updated")
+		require.NoError(t, r.Handle(context.Background(), msg))
+		require.Len(t, dlq.PublishCalls(), 1)
+		require.Equal(t, msg, dlq.PublishCalls()[0].Msg)
+	})
+
+	t.Run("unknown type dead letter fails", func(t *testing.T) {
+		t.Parallel()
+
+		publishErr := errors.New("publish error")
+		dlq := &PublisherMock{
+			PublishFunc: func(context.Context, messenger.Message) error { return publishErr },
+		}
+		r := messenger.NewRouter("orders", typeKey, messenger.WithUnknownDeadLetter(dlq))
+
+		require.ErrorIs(t, r.Handle(context.Background(), newMsg(t, "order.updated")), publishErr)
+	})
+
+	t.Run("routes to typed subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		var got orderCreated
+		typed := messenger.NewTypedSubscription(
+			"order.created",
+			func(_ context.Context, payload orderCreated, _ messenger.Metadata) error {
+				got = payload
+				return nil
+			},
+		)
+
+		r := messenger.NewRouter("orders", typeKey)
+		r.AddRoute("order.created", typed.Handle)
+
+		msg := newMsg(t, "order.created")
+		msg.MsgPayload = []byte(`{"order_id":"order-1"}`)
+
+		require.NoError(t, r.Handle(context.Background(), msg))
+		require.Equal(t, "order-1", got.OrderID)
+	})
+}
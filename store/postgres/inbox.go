@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/x4b1/messenger"
+)
+
+// DefaultInboxTable is the table name that will be used for the inbox if no other table name provided.
+const DefaultInboxTable = "inbox"
+
+// Querier knows how to run a sql query and scan a single row.
+type Querier interface {
+	Executor
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+}
+
+// Tx defines a database transaction where the processed messages are recorded
+// along with the handler business writes.
+type Tx interface {
+	Querier
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// NewInbox returns a postgres inbox initialised with the given connection instance and config.
+func NewInbox(ctx context.Context, db Instance, opts ...Option) (*Inbox, error) {
+	if err := db.Ping(ctx); err != nil {
+		return nil, err
+	}
+
+	i := Inbox{db: db}
+
+	for _, opt := range opts {
+		opt(&i.config)
+	}
+
+	var err error
+	if i.config.schema == "" {
+		if i.config.schema, err = currentSchema(ctx, db); err != nil {
+			return nil, err
+		}
+		if i.config.schema == "" {
+			return nil, ErrMissingSchemaName
+		}
+	}
+
+	if i.config.table == "" {
+		i.config.table = DefaultInboxTable
+	}
+
+	if err := i.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// Inbox keeps track of the messages already processed by each subscription,
+// allowing consumers to skip duplicated deliveries.
+type Inbox struct {
+	db Instance
+
+	config config
+}
+
+// Record saves the message as processed by the subscription using the given transaction.
+// It returns false if the message was already processed.
+func (i *Inbox) Record(ctx context.Context, tx Querier, subscription, msgID string) (bool, error) {
+	var inserted int
+	err := tx.QueryRow(
+		ctx,
+		fmt.Sprintf(
+			`WITH inserted AS (
+				INSERT INTO %q.%q (subscription, message_id) VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+				RETURNING 1
+			)
+			SELECT COUNT(*) FROM inserted`,
+			i.config.schema,
+			i.config.table,
+		),
+		subscription,
+		msgID,
+	).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("recording processed message: %w", err)
+	}
+
+	return inserted == 1, nil
+}
+
+// Handle records the message as processed and calls the handler within the given transaction.
+// If the message was already processed by the subscription it skips the handler.
+// The transaction is committed if the handler succeeds, otherwise it is rolled back, also if the handler panics.
+func (i *Inbox) Handle(
+	ctx context.Context,
+	tx Tx,
+	subscription string,
+	msg messenger.Message,
+	h messenger.SubscriptionHandler,
+) error {
+	// rolls back on errors and handler panics, once committed it does nothing.
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	recorded, err := i.Record(ctx, tx, subscription, msg.ID())
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return nil
+	}
+
+	if err := h(ctx, msg); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing processed message: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteProcessedByExpiration performs a hard delete of the processed messages
+// older than the given duration.
+func (i *Inbox) DeleteProcessedByExpiration(ctx context.Context, d time.Duration) error {
+	err := i.db.Exec(
+		ctx,
+		fmt.Sprintf(
+			"DELETE FROM %q.%q WHERE processed_at < $1;",
+			i.config.schema,
+			i.config.table,
+		),
+		time.Now().UTC().Add(-d),
+	)
+	if err != nil {
+		return fmt.Errorf("deleting processed messages: %w", err)
+	}
+
+	return nil
+}
+
+// ensureTable creates if not exists the table to store processed messages.
+func (i *Inbox) ensureTable(ctx context.Context) error {
+	// Check if table already exists, we cannot use `CREATE TABLE IF NOT EXISTS`,
+	// maybe the user does not have permissions to CREATE and it will fail
+	row := i.db.QueryRow(
+		ctx,
+		`SELECT COUNT(1) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2 LIMIT 1`,
+		i.config.schema,
+		i.config.table,
+	)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return fmt.Errorf("ensuring inbox table exists: %w", err)
+	}
+
+	if count == 1 {
+		return nil
+	}
+
+	err := i.db.Exec(
+		ctx,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s"."%s" (
+			subscription TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			processed_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+			PRIMARY KEY (subscription, message_id)
+		)`,
+			i.config.schema,
+			i.config.table,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("creating inbox table: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/store/postgres"
+	store "github.com/x4b1/messenger/store/postgres/pgx"
+)
+
+// NewTestInbox returns an inbox within a test transaction, the inbox transactions are savepoints of it.
+func NewTestInbox(t *testing.T) (*store.Inbox, pgx.Tx) {
+	t.Helper()
+
+	tx, err := connPool.Begin(context.TODO())
+	require.NoError(t, err)
+
+	i, err := store.NewInbox(context.Background(), testInstance{tx})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if err := tx.Rollback(context.TODO()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return i, tx
+}
+
+// countCalls returns a handler that counts the number of calls.
+func countCalls(calls *int) store.TxHandler {
+	return func(context.Context, pgx.Tx, messenger.Message) error {
+		*calls++
+		return nil
+	}
+}
+
+func TestInboxRecord(t *testing.T) {
+	t.Parallel()
+
+	inbox, _ := NewTestInbox(t)
+	ctx := context.Background()
+
+	msg, err := messenger.NewMessage([]byte("message"))
+	require.NoError(t, err)
+
+	calls1, calls2 := 0, 0
+	sub1 := inbox.Subscription("sub-1", countCalls(&calls1))
+	sub2 := inbox.Subscription("sub-2", countCalls(&calls2))
+
+	require.NoError(t, sub1.Handle(ctx, msg))
+	require.NoError(t, sub1.Handle(ctx, msg))
+	require.NoError(t, sub2.Handle(ctx, msg))
+
+	require.Equal(t, 1, calls1)
+	require.Equal(t, 1, calls2)
+}
+
+func TestInboxHandle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("skips processed messages", func(t *testing.T) {
+		t.Parallel()
+
+		inbox, _ := NewTestInbox(t)
+		ctx := context.Background()
+
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		calls := 0
+		sub := inbox.Subscription("sub", countCalls(&calls))
+
+		require.NoError(t, sub.Handle(ctx, msg))
+		require.NoError(t, sub.Handle(ctx, msg))
+
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("handler fails rolls back", func(t *testing.T) {
+		t.Parallel()
+
+		inbox, _ := NewTestInbox(t)
+		ctx := context.Background()
+
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		handlerErr := errors.New("handler error")
+		require.ErrorIs(t, inbox.Subscription("sub", func(context.Context, pgx.Tx, messenger.Message) error {
+			return handlerErr
+		}).Handle(ctx, msg), handlerErr)
+
+		calls := 0
+		require.NoError(t, inbox.Subscription("sub", countCalls(&calls)).Handle(ctx, msg))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("handler panics rolls back", func(t *testing.T) {
+		t.Parallel()
+
+		inbox, _ := NewTestInbox(t)
+		ctx := context.Background()
+
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		require.Panics(t, func() {
+			_ = inbox.Subscription("sub", func(context.Context, pgx.Tx, messenger.Message) error {
+				panic("handler panic")
+			}).Handle(ctx, msg)
+		})
+
+		calls := 0
+		require.NoError(t, inbox.Subscription("sub", countCalls(&calls)).Handle(ctx, msg))
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestInboxDeleteProcessedByExpiration(t *testing.T) {
+	t.Parallel()
+
+	inbox, tx := NewTestInbox(t)
+	ctx := context.Background()
+
+	insert := fmt.Sprintf(
+		`INSERT INTO %q (subscription, message_id, processed_at) VALUES ($1, $2, $3)`,
+		postgres.DefaultInboxTable,
+	)
+	_, err := tx.Exec(ctx, insert, "sub", "expired", time.Now().UTC().AddDate(0, 0, -2))
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, insert, "sub", "not-expired", time.Now().UTC())
+	require.NoError(t, err)
+
+	require.NoError(t, inbox.DeleteProcessedByExpiration(ctx, 24*time.Hour))
+
+	calls := 0
+	sub := inbox.Subscription("sub", countCalls(&calls))
+	require.NoError(t, sub.Handle(ctx, &messenger.GenericMessage{MsgID: "expired"}))
+	require.Equal(t, 1, calls)
+
+	require.NoError(t, sub.Handle(ctx, &messenger.GenericMessage{MsgID: "not-expired"}))
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,54 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/store/postgres"
+)
+
+// InboxInstance is an abstraction of pgx API able to begin transactions,
+// to allow to use pgx.Conn or pgxpool.Pool.
+type InboxInstance interface {
+	Instance
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// TxHandler defines a function to process a message within the given transaction,
+// if something fails returns an error and the transaction is rolled back.
+type TxHandler func(ctx context.Context, tx pgx.Tx, msg messenger.Message) error
+
+// NewInbox returns Inbox initialised with the given connection instance and config.
+func NewInbox(ctx context.Context, i InboxInstance, opts ...postgres.Option) (*Inbox, error) {
+	inbox, err := postgres.NewInbox(ctx, newWrapper(i), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Inbox{inbox, i}, nil
+}
+
+// Inbox is the instance to deduplicate received messages in PostgreSQL database.
+type Inbox struct {
+	*postgres.Inbox
+
+	db InboxInstance
+}
+
+// Subscription returns a messenger.Subscription that skips the messages already processed
+// by the subscription name, recording the processed messages in the same transaction
+// the handler uses for its business writes.
+func (i *Inbox) Subscription(name string, h TxHandler) messenger.Subscription {
+	return messenger.NewSubscription(name, func(ctx context.Context, msg messenger.Message) error {
+		tx, err := i.db.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("beginning inbox transaction: %w", err)
+		}
+
+		return i.Handle(ctx, &txWrapper{tx}, name, msg, func(ctx context.Context, msg messenger.Message) error {
+			return h(ctx, tx, msg)
+		})
+	})
+}
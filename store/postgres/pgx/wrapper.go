@@ -58,3 +58,27 @@ func (ew *execWrapper) Exec(ctx context.Context, sql string, args ...any) error
 
 	return err
 }
+
+var _ postgres.Tx = (*txWrapper)(nil)
+
+type txWrapper struct {
+	tx pgx.Tx
+}
+
+func (w *txWrapper) Exec(ctx context.Context, sql string, args ...any) error {
+	_, err := w.tx.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (w *txWrapper) QueryRow(ctx context.Context, sql string, args ...any) postgres.Row {
+	return w.tx.QueryRow(ctx, sql, args...)
+}
+
+func (w *txWrapper) Commit(ctx context.Context) error {
+	return w.tx.Commit(ctx)
+}
+
+func (w *txWrapper) Rollback(ctx context.Context) error {
+	return w.tx.Rollback(ctx)
+}
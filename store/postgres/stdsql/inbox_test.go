@@ -0,0 +1,95 @@
+package stdsql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+	"github.com/x4b1/messenger/internal/testhelpers"
+	"github.com/x4b1/messenger/store/postgres"
+	store "github.com/x4b1/messenger/store/postgres/stdsql"
+)
+
+func TestInbox_Subscription(t *testing.T) {
+	ctx := context.TODO()
+
+	pgConn, err := testhelpers.CreatePostgresContainer(ctx)
+	require.NoError(t, err)
+
+	db, err := sql.Open("pgx", pgConn.ConnectionString)
+	require.NoError(t, err)
+
+	inbox, err := store.NewInbox(ctx, db, postgres.WithTableName("stdsql_inbox"))
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS stdsql_inbox_effects (id TEXT)`)
+	require.NoError(t, err)
+
+	countEffects := func(msgID string) int {
+		var count int
+		require.NoError(t, db.QueryRowContext(
+			ctx,
+			`SELECT COUNT(*) FROM stdsql_inbox_effects WHERE id = $1`,
+			msgID,
+		).Scan(&count))
+
+		return count
+	}
+
+	t.Run("skips duplicated messages", func(t *testing.T) {
+		sub := inbox.Subscription("test", func(ctx context.Context, tx *sql.Tx, msg messenger.Message) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO stdsql_inbox_effects (id) VALUES ($1)`, msg.ID())
+			return err
+		})
+
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		require.NoError(t, sub.Handle(ctx, msg))
+		require.NoError(t, sub.Handle(ctx, msg))
+
+		require.Equal(t, 1, countEffects(msg.ID()))
+	})
+
+	t.Run("handler fails rolls back", func(t *testing.T) {
+		handlerErr := errors.New("handler error")
+		fail := true
+		sub := inbox.Subscription("test", func(ctx context.Context, tx *sql.Tx, msg messenger.Message) error {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO stdsql_inbox_effects (id) VALUES ($1)`, msg.ID()); err != nil {
+				return err
+			}
+			if fail {
+				return handlerErr
+			}
+			return nil
+		})
+
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, sub.Handle(ctx, msg), handlerErr)
+		require.Equal(t, 0, countEffects(msg.ID()))
+
+		fail = false
+		require.NoError(t, sub.Handle(ctx, msg))
+		require.Equal(t, 1, countEffects(msg.ID()))
+	})
+
+	t.Run("handler panics rolls back", func(t *testing.T) {
+		msg, err := messenger.NewMessage([]byte("message"))
+		require.NoError(t, err)
+
+		require.Panics(t, func() {
+			_ = inbox.Subscription("test", func(ctx context.Context, tx *sql.Tx, msg messenger.Message) error {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO stdsql_inbox_effects (id) VALUES ($1)`, msg.ID()); err != nil {
+					return err
+				}
+				panic("handler panic")
+			}).Handle(ctx, msg)
+		})
+		require.Equal(t, 0, countEffects(msg.ID()))
+	})
+}
@@ -54,3 +54,27 @@ type rows struct {
 func (r *rows) Close() {
 	_ = r.Rows.Close()
 }
+
+var _ postgres.Tx = (*txConn)(nil)
+
+type txConn struct {
+	tx *sql.Tx
+}
+
+func (c *txConn) Exec(ctx context.Context, sql string, args ...any) error {
+	_, err := c.tx.ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (c *txConn) QueryRow(ctx context.Context, sql string, args ...any) postgres.Row {
+	return c.tx.QueryRowContext(ctx, sql, args...)
+}
+
+func (c *txConn) Commit(context.Context) error {
+	return c.tx.Commit()
+}
+
+func (c *txConn) Rollback(context.Context) error {
+	return c.tx.Rollback()
+}
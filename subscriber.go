@@ -0,0 +1,102 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// Subscriber is the interface that wraps the basic message consuming from a broker.
+type Subscriber interface {
+	// Register adds subscriptions to be consumed once the subscriber starts listening.
+	Register(subs ...Subscription)
+	// Listen starts consuming messages for the registered subscriptions.
+	// It blocks until the context is cancelled, the subscriber is closed or an error occurs.
+	Listen(ctx context.Context) error
+	// Close stops receiving new messages and waits until the messages being processed finish
+	// or the given context is done.
+	Close(ctx context.Context) error
+}
+
+// RunnerOption defines the optional parameters for Runner.
+type RunnerOption func(*Runner)
+
+// WithShutdownTimeout replaces the default time given to subscribers to close gracefully.
+func WithShutdownTimeout(d time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.shutdownTimeout = d
+	}
+}
+
+// NewRunner returns a `Runner` instance hosting the given subscribers with defaults.
+//   - Shutdown timeout: 30s
+func NewRunner(subs []Subscriber, opts ...RunnerOption) *Runner {
+	r := Runner{
+		subs:            subs,
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return &r
+}
+
+// Runner hosts multiple subscribers under the same context and lifecycle,
+// allowing to mix subscribers from different brokers.
+type Runner struct {
+	subs            []Subscriber
+	shutdownTimeout time.Duration
+}
+
+// Run starts listening all the subscribers and blocks until the context is cancelled,
+// any of the subscribers fails or all of them stop listening. Once it stops, every subscriber is closed gracefully.
+func (r *Runner) Run(ctx context.Context) error {
+	g, gctx := errgroup.WithContext(ctx)
+	var listening sync.WaitGroup
+	for _, sub := range r.subs {
+		listening.Add(1)
+		g.Go(func() error {
+			defer listening.Done()
+
+			return sub.Listen(gctx)
+		})
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		listening.Wait()
+		close(stopped)
+	}()
+
+	g.Go(func() error {
+		select {
+		case <-gctx.Done():
+		case <-stopped:
+		}
+
+		return r.close(context.WithoutCancel(ctx))
+	})
+
+	return g.Wait()
+}
+
+// close closes all the subscribers waiting at most the shutdown timeout.
+func (r *Runner) close(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.shutdownTimeout)
+	defer cancel()
+
+	errs := make([]error, 0, len(r.subs))
+	for _, sub := range r.subs {
+		if err := sub.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+package messenger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	t.Run("listens all subscribers and closes them on cancel", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		newSub := func() *SubscriberMock {
+			return &SubscriberMock{
+				ListenFunc: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+			}
+		}
+		subs := []*SubscriberMock{newSub(), newSub()}
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+		}()
+
+		require.NoError(t, messenger.NewRunner([]messenger.Subscriber{subs[0], subs[1]}).Run(ctx))
+
+		for _, sub := range subs {
+			require.Len(t, sub.ListenCalls(), 1)
+			require.Len(t, sub.CloseCalls(), 1)
+		}
+	})
+
+	t.Run("subscriber fails stops all", func(t *testing.T) {
+		t.Parallel()
+
+		listenErr := errors.New("listen error")
+		failing := &SubscriberMock{
+			ListenFunc: func(context.Context) error {
+				return listenErr
+			},
+		}
+		running := &SubscriberMock{
+			ListenFunc: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			},
+		}
+
+		err := messenger.NewRunner([]messenger.Subscriber{failing, running}).Run(context.Background())
+		require.ErrorIs(t, err, listenErr)
+
+		require.Len(t, failing.CloseCalls(), 1)
+		require.Len(t, running.CloseCalls(), 1)
+	})
+
+	t.Run("returns once all subscribers stop listening", func(t *testing.T) {
+		t.Parallel()
+
+		subs := []*SubscriberMock{{}, {}}
+
+		require.NoError(t, messenger.NewRunner([]messenger.Subscriber{subs[0], subs[1]}).Run(context.Background()))
+
+		for _, sub := range subs {
+			require.Len(t, sub.ListenCalls(), 1)
+			require.Len(t, sub.CloseCalls(), 1)
+		}
+	})
+
+	t.Run("closing fails returns error", func(t *testing.T) {
+		t.Parallel()
+
+		closeErr := errors.New("close error")
+		sub := &SubscriberMock{
+			ListenFunc: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			},
+			CloseFunc: func(context.Context) error {
+				return closeErr
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, messenger.NewRunner([]messenger.Subscriber{sub}).Run(ctx), closeErr)
+	})
+
+	t.Run("closes with shutdown timeout", func(t *testing.T) {
+		t.Parallel()
+
+		timeout := time.Minute
+		sub := &SubscriberMock{
+			ListenFunc: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			},
+			CloseFunc: func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+				require.WithinDuration(t, time.Now().Add(timeout), deadline, time.Second)
+
+				return nil
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.NoError(t, messenger.NewRunner(
+			[]messenger.Subscriber{sub},
+			messenger.WithShutdownTimeout(timeout),
+		).Run(ctx))
+	})
+}
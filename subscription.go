@@ -7,9 +7,29 @@ import (
 // SubscriptionHandler defines a function to process a message, if something fails returns an error.
 type SubscriptionHandler func(ctx context.Context, msg Message) error
 
+// SubscriptionMiddleware wraps a SubscriptionHandler to add behaviour before or after handling a message.
+type SubscriptionMiddleware func(SubscriptionHandler) SubscriptionHandler
+
 // NewSubscription returns a Subscription handler.
-func NewSubscription(name string, h SubscriptionHandler) Subscription {
-	return &subscription{name, h}
+// The given middlewares wrap the handler, the first one being the outermost.
+func NewSubscription(name string, h SubscriptionHandler, mws ...SubscriptionMiddleware) Subscription {
+	return &subscription{name, chain(h, mws...)}
+}
+
+// WithMiddleware returns a Subscription that handles the messages through the given middlewares
+// before calling the given subscription, the first middleware being the outermost.
+func WithMiddleware(sub Subscription, mws ...SubscriptionMiddleware) Subscription {
+	return &subscription{sub.Name(), chain(sub.Handle, mws...)}
+}
+
+// chain wraps the handler with the middlewares in reverse order,
+// so the first middleware is the first one receiving the message.
+func chain(h SubscriptionHandler, mws ...SubscriptionMiddleware) SubscriptionHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
 }
 
 type subscription struct {
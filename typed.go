@@ -0,0 +1,87 @@
+package messenger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// The DecoderFunc type is an adapter to allow the use of
+// ordinary functions as Decoder. If f is a function
+// with the appropriate signature, DecoderFunc(f) is a
+// [Decoder] that calls f.
+type DecoderFunc func(data []byte, v any) error
+
+// Decode calls f(data, v).
+func (f DecoderFunc) Decode(data []byte, v any) error {
+	return f(data, v)
+}
+
+// Decoder knows how to decode a message payload into a value.
+type Decoder interface {
+	Decode(data []byte, v any) error
+}
+
+// DefaultDecoder decodes the message payload the same way the stores encode it by default.
+// If the value is a raw type the payload is copied as it is,
+// if not it will try to unmarshal the payload as json.
+func DefaultDecoder() DecoderFunc {
+	return func(data []byte, v any) error {
+		switch t := v.(type) {
+		case *string:
+			*t = string(data)
+			return nil
+		case *[]byte:
+			*t = data
+			return nil
+		}
+
+		return json.Unmarshal(data, v)
+	}
+}
+
+// TypedHandler defines a function to process a decoded message payload, if something fails returns an error.
+type TypedHandler[T any] func(ctx context.Context, payload T, md Metadata) error
+
+// TypedOption defines the optional parameters for typed subscriptions.
+type TypedOption func(*typedConfig)
+
+type typedConfig struct {
+	decoder Decoder
+	mws     []SubscriptionMiddleware
+}
+
+// WithDecoder replaces the default payload decoder.
+func WithDecoder(d Decoder) TypedOption {
+	return func(c *typedConfig) {
+		c.decoder = d
+	}
+}
+
+// WithTypedMiddleware wraps the typed subscription with the given middlewares.
+func WithTypedMiddleware(mws ...SubscriptionMiddleware) TypedOption {
+	return func(c *typedConfig) {
+		c.mws = append(c.mws, mws...)
+	}
+}
+
+// NewTypedSubscription returns a Subscription that decodes the message payload into T
+// before calling the handler. Decoding failures are returned as permanent errors,
+// as retrying the message will not fix them.
+func NewTypedSubscription[T any](name string, h TypedHandler[T], opts ...TypedOption) Subscription {
+	cfg := typedConfig{
+		decoder: DefaultDecoder(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return NewSubscription(name, func(ctx context.Context, msg Message) error {
+		var payload T
+		if err := cfg.decoder.Decode(msg.Payload(), &payload); err != nil {
+			return Permanent(fmt.Errorf("decoding payload of message %s: %w", msg.ID(), err))
+		}
+
+		return h(ctx, payload, msg.Metadata())
+	}, cfg.mws...)
+}
@@ -0,0 +1,137 @@
+package messenger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x4b1/messenger"
+)
+
+type orderCreated struct {
+	OrderID string `json:"order_id"`
+	Amount  int    `json:"amount"`
+}
+
+func TestTypedSubscription(t *testing.T) {
+	t.Parallel()
+
+	md := messenger.Metadata{"type": "order.created"}
+
+	t.Run("decodes json payload", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			got   orderCreated
+			gotMD messenger.Metadata
+		)
+		sub := messenger.NewTypedSubscription(
+			"orders",
+			func(_ context.Context, payload orderCreated, md messenger.Metadata) error {
+				got = payload
+				gotMD = md
+				return nil
+			},
+		)
+
+		msg := &messenger.GenericMessage{
+			MsgID:       "e3b0c442-98fc-1c14-9afb-f4c8996fb924",
+			MsgMetadata: md,
+			MsgPayload:  []byte(`{"order_id":"order-1","amount":10}`),
+		}
+
+		require.Equal(t, "orders", sub.Name())
+		require.NoError(t, sub.Handle(context.Background(), msg))
+		require.Equal(t, orderCreated{OrderID: "order-1", Amount: 10}, got)
+		require.Equal(t, md, gotMD)
+	})
+
+	t.Run("raw payload", func(t *testing.T) {
+		t.Parallel()
+
+		var got string
+		sub := messenger.NewTypedSubscription(
+			"raw",
+			func(_ context.Context, payload string, _ messenger.Metadata) error {
+				got = payload
+				return nil
+			},
+		)
+
+		msg, err := messenger.NewMessage([]byte("not a json"))
+		require.NoError(t, err)
+
+		require.NoError(t, sub.Handle(context.Background(), msg))
+		require.Equal(t, "not a json", got)
+	})
+
+	t.Run("decoding fails returns permanent error", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		sub := messenger.NewTypedSubscription(
+			"orders",
+			func(context.Context, orderCreated, messenger.Metadata) error {
+				calls++
+				return nil
+			},
+			messenger.WithTypedMiddleware(messenger.Retry(3, 0)),
+		)
+
+		msg, err := messenger.NewMessage([]byte("not a json"))
+		require.NoError(t, err)
+
+		err = sub.Handle(context.Background(), msg)
+		require.Error(t, err)
+		require.True(t, messenger.IsPermanent(err))
+		require.Zero(t, calls)
+	})
+
+	t.Run("custom decoder", func(t *testing.T) {
+		t.Parallel()
+
+		decodeErr := errors.New("decode error")
+		sub := messenger.NewTypedSubscription(
+			"orders",
+			func(context.Context, orderCreated, messenger.Metadata) error { return nil },
+			messenger.WithDecoder(messenger.DecoderFunc(func([]byte, any) error {
+				return decodeErr
+			})),
+		)
+
+		msg, err := messenger.NewMessage([]byte(`{}`))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, sub.Handle(context.Background(), msg), decodeErr)
+	})
+
+	t.Run("handler error is returned", func(t *testing.T) {
+		t.Parallel()
+
+		handlerErr := errors.New("handler error")
+		sub := messenger.NewTypedSubscription(
+			"orders",
+			func(context.Context, orderCreated, messenger.Metadata) error { return handlerErr },
+		)
+
+		msg, err := messenger.NewMessage([]byte(`{}`))
+		require.NoError(t, err)
+
+		err = sub.Handle(context.Background(), msg)
+		require.ErrorIs(t, err, handlerErr)
+		require.False(t, messenger.IsPermanent(err))
+	})
+}
+
+func TestPermanent(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, messenger.Permanent(nil))
+
+	baseErr := errors.New("some error")
+	err := messenger.Permanent(baseErr)
+	require.ErrorIs(t, err, baseErr)
+	require.True(t, messenger.IsPermanent(err))
+	require.False(t, messenger.IsPermanent(baseErr))
+}